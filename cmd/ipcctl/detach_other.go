@@ -0,0 +1,10 @@
+//go:build !windows
+
+package main
+
+import "os/exec"
+
+func runDetached(path string, args []string) error {
+	cmd := exec.Command(path, args...)
+	return cmd.Start()
+}
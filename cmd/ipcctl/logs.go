@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"wincron/internal/ipc"
+)
+
+const logsUsage = "usage: wincronctl logs export <file> [--job <job name|folder>] [--status <status>] [--from <date>] [--to <date>] [--format csv|jsonl|html]"
+
+// parseLogsArgs reads "logs export". The file path is made absolute here
+// because the GUI process resolves relative paths against its own directory.
+func parseLogsArgs(args []string, req *ipc.Request) error {
+	if len(args) == 0 || !strings.EqualFold(strings.TrimSpace(args[0]), "export") {
+		return errors.New(logsUsage)
+	}
+	req.Cmd = "export_logs"
+
+	options := map[string]*string{
+		"--job":    &req.Target,
+		"--status": &req.Status,
+		"--from":   &req.From,
+		"--to":     &req.To,
+		"--format": &req.Format,
+	}
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "--") {
+			if req.Path != "" {
+				return errors.New(logsUsage)
+			}
+			req.Path = arg
+			continue
+		}
+		name, value, hasValue := strings.Cut(arg, "=")
+		dst, ok := options[name]
+		if !ok {
+			return fmt.Errorf("unknown logs export option: %s", arg)
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return errors.New(logsUsage)
+			}
+			i++
+			value = args[i]
+		}
+		*dst = value
+	}
+
+	if strings.TrimSpace(req.Path) == "" {
+		return errors.New(logsUsage)
+	}
+	if abs, err := filepath.Abs(req.Path); err == nil {
+		req.Path = abs
+	}
+	return nil
+}
@@ -5,10 +5,8 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
-	"syscall"
 
 	"wincron/internal/ipc"
 )
@@ -25,10 +23,14 @@ Commands:
       Enable WinCron or matching jobs
   disable [job name|folder]
       Disable WinCron or matching jobs
-  run <job name>
-      Run matching jobs immediately
-  import <yaml-file|-> [--overwrite|--coexist]
+  run <job name> [--param k=v]... [--env K=V]... [--workdir <dir>] [-- <extra args>]
+      Run matching jobs immediately, optionally with params and one-off overrides
+  import <yaml-file|-> [--overwrite|--coexist] [--strict]
       Import jobs from YAML
+  stats <job name> [--from <date>] [--to <date>] [--json]
+      Show run statistics of matching jobs
+  logs export <file> [--job <job name|folder>] [--status <status>] [--from <date>] [--to <date>] [--format csv|jsonl|html]
+      Export stored runs; the format defaults to the file extension
   quit
       Ask the WinCron GUI process to exit
 
@@ -38,7 +40,7 @@ Tips:
 `
 
 const importHelpText = `Usage:
-  wincronctl import <yaml-file|-> [--overwrite|--coexist]
+  wincronctl import <yaml-file|-> [--overwrite|--coexist] [--strict]
 
 Supported YAML formats:
   1. A raw YAML list of jobs
@@ -49,20 +51,32 @@ Recommendations:
   - Use stable unique job names, for example AI/daily-report
   - Use @reboot for startup jobs; do not set runAtStartup manually
   - Do not write runtime fields: id, consecutiveFailures, executedCount, lastExecutedAt, nextRunAt
+  - Use --strict to reject the whole import when a job has a missing executable, workDir, bad cron or hotkey conflict
 
 Useful values:
   - concurrencyPolicy: skip | kill_old | allow
+  - shell: cmd | powershell | pwsh | sh | bash (runs the inline script field instead of command)
+  - successExitCodes: "0,2-7" (list or ranges); failIfOutputMatches / succeedIfOutputMatches: regular expressions
+  - stdinSource: text | file | job (stdin holds the text, the file path, or the job name whose last output is piped in)
   - flagProcessCreation: CREATE_NEW_CONSOLE | CREATE_NO_WINDOW | DETACHED_PROCESS
+  - outputEncoding: auto | utf-8 | utf-16le | gbk | cp437 | ... (auto detects BOMs and UTF-16, then falls back to the OEM code page)
+  - ansiMode: strip | spans (default keeps escape codes; spans stores colors separately from the plain text)
+  - steps: ordered list of {name, command, args, shell, script, workDir, timeout, continueOnError}; replaces command
+  - matrix: {items: [...] or file: <path, one item per line>, parallelism}; runs once per item with {{.Item}} in args or workDir
+  - params: list of {name, type: string | number | bool, default, choices, required}; use {{.Params.name}} in args or workDir
+  - logRetention: {maxEntriesPerJob, maxAgeDays, failedMaxEntriesPerJob, failedMaxAgeDays}; overrides the global retention settings
+  - stopSignal: interrupt | ctrl_break | wm_close (polite stop before the hard kill); stopGracePeriod: seconds, default 10
 
 Examples:
   wincronctl import .\task.yml --overwrite
   Get-Content .\task.yml -Raw | wincronctl import - --overwrite
+  wincronctl import .\task.yml --strict
   wincronctl import --example
 
 See docs/import-yaml.md in the repository for a complete example.
 `
 
-const importUsage = "usage: wincronctl import <yaml-file|-> [--overwrite|--coexist]"
+const importUsage = "usage: wincronctl import <yaml-file|-> [--overwrite|--coexist] [--strict]"
 
 const importExampleYAML = `# AI-friendly format: use a raw YAML list of jobs.
 # Do not write id, runAtStartup, consecutiveFailures, executedCount, lastExecutedAt, or nextRunAt.
@@ -82,6 +96,13 @@ const importExampleYAML = `# AI-friendly format: use a raw YAML list of jobs.
   enabled: true
   maxConsecutiveFailures: 3
 
+- name: AI/find-logs
+  cron: "*/30 * * * *"
+  shell: cmd
+  script: |
+    dir /b C:\Logs | findstr error
+  enabled: true
+
 - name: AI/on-boot-check
   cron: "@reboot"
   command: "cmd.exe"
@@ -135,7 +156,7 @@ func isControlCommand(args []string) bool {
 		return false
 	}
 	switch strings.ToLower(strings.TrimSpace(args[0])) {
-	case "disable", "enable", "status", "quit", "open", "run", "import":
+	case "disable", "enable", "status", "quit", "open", "run", "import", "stats", "logs":
 		return true
 	default:
 		return false
@@ -170,7 +191,12 @@ func runControlCommand(args []string) int {
 		return 1
 	}
 
-	if req.Cmd == "status" {
+	if req.Cmd == "stats" {
+		if err := printStats(os.Stdout, resp.Data, req.Format == "json"); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return 1
+		}
+	} else if req.Cmd == "status" {
 		if resp.GlobalEnabled != nil {
 			if *resp.GlobalEnabled {
 				fmt.Println("enabled")
@@ -228,29 +254,131 @@ func parseControlRequest(args []string) (ipc.Request, error) {
 			req.Target = strings.Join(args[1:], " ")
 		}
 	case "run":
-		if len(args) < 2 {
-			return ipc.Request{}, errors.New("usage: wincronctl run <job name>")
+		if err := parseRunArgs(args[1:], &req); err != nil {
+			return ipc.Request{}, err
+		}
+	case "stats":
+		if err := parseStatsArgs(args[1:], &req); err != nil {
+			return ipc.Request{}, err
+		}
+	case "logs":
+		if err := parseLogsArgs(args[1:], &req); err != nil {
+			return ipc.Request{}, err
 		}
-		req.Target = strings.Join(args[1:], " ")
 	case "import":
-		payload, strategy, err := parseImportArgs(args[1:])
+		payload, strategy, strict, err := parseImportArgs(args[1:])
 		if err != nil {
 			return ipc.Request{}, err
 		}
 		req.Payload = payload
 		req.ConflictStrategy = strategy
+		req.Strict = strict
 	}
 
 	return req, nil
 }
 
-func parseImportArgs(args []string) (string, string, error) {
+const runUsage = "usage: wincronctl run <job name> [--param k=v]... [--env K=V]... [--workdir <dir>] [-- <extra args>]"
+
+// parseRunArgs reads the job name and the one-off overrides. Everything after
+// "--" is appended to the job's arguments as is.
+func parseRunArgs(args []string, req *ipc.Request) error {
+	var nameParts []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			req.Args = append([]string{}, args[i+1:]...)
+			i = len(args)
+		case arg == "--env" || arg == "-e":
+			if i+1 >= len(args) {
+				return errors.New(runUsage)
+			}
+			i++
+			if err := addRunEnv(req, args[i]); err != nil {
+				return err
+			}
+		case strings.HasPrefix(arg, "--env="):
+			if err := addRunEnv(req, strings.TrimPrefix(arg, "--env=")); err != nil {
+				return err
+			}
+		case arg == "--param" || arg == "-p":
+			if i+1 >= len(args) {
+				return errors.New(runUsage)
+			}
+			i++
+			if err := addRunParam(req, args[i]); err != nil {
+				return err
+			}
+		case strings.HasPrefix(arg, "--param="):
+			if err := addRunParam(req, strings.TrimPrefix(arg, "--param=")); err != nil {
+				return err
+			}
+		case arg == "--workdir":
+			if i+1 >= len(args) {
+				return errors.New(runUsage)
+			}
+			i++
+			req.WorkDir = absRunWorkDir(args[i])
+		case strings.HasPrefix(arg, "--workdir="):
+			req.WorkDir = absRunWorkDir(strings.TrimPrefix(arg, "--workdir="))
+		case strings.HasPrefix(arg, "--"):
+			return fmt.Errorf("unknown run option: %s", arg)
+		default:
+			nameParts = append(nameParts, arg)
+		}
+	}
+	req.Target = strings.Join(nameParts, " ")
+	if strings.TrimSpace(req.Target) == "" {
+		return errors.New(runUsage)
+	}
+	return nil
+}
+
+// absRunWorkDir resolves dir against the caller's directory; wincron runs
+// elsewhere and would resolve it against its own.
+func absRunWorkDir(dir string) string {
+	if strings.TrimSpace(dir) == "" || strings.Contains(dir, "{{") {
+		return dir
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		return abs
+	}
+	return dir
+}
+
+func addRunEnv(req *ipc.Request, pair string) error {
+	key, value, ok := strings.Cut(pair, "=")
+	if !ok || strings.TrimSpace(key) == "" {
+		return fmt.Errorf("invalid --env value, expected K=V: %s", pair)
+	}
+	if req.Env == nil {
+		req.Env = map[string]string{}
+	}
+	req.Env[strings.TrimSpace(key)] = value
+	return nil
+}
+
+func addRunParam(req *ipc.Request, pair string) error {
+	name, value, ok := strings.Cut(pair, "=")
+	if !ok || strings.TrimSpace(name) == "" {
+		return fmt.Errorf("invalid --param value, expected name=value: %s", pair)
+	}
+	if req.Params == nil {
+		req.Params = map[string]string{}
+	}
+	req.Params[strings.TrimSpace(name)] = value
+	return nil
+}
+
+func parseImportArgs(args []string) (string, string, bool, error) {
 	if len(args) == 0 {
-		return "", "", errors.New(importUsage)
+		return "", "", false, errors.New(importUsage)
 	}
 
 	source := ""
 	strategy := "overwrite"
+	strict := false
 	for _, raw := range args {
 		arg := strings.TrimSpace(raw)
 		switch strings.ToLower(arg) {
@@ -258,29 +386,31 @@ func parseImportArgs(args []string) (string, string, error) {
 			strategy = "overwrite"
 		case "--coexist":
 			strategy = "coexist"
+		case "--strict":
+			strict = true
 		default:
 			if strings.HasPrefix(arg, "--") {
-				return "", "", fmt.Errorf("unknown import option: %s", raw)
+				return "", "", false, fmt.Errorf("unknown import option: %s", raw)
 			}
 			if source != "" {
-				return "", "", errors.New(importUsage)
+				return "", "", false, errors.New(importUsage)
 			}
 			source = raw
 		}
 	}
 
 	if strings.TrimSpace(source) == "" {
-		return "", "", errors.New(importUsage)
+		return "", "", false, errors.New(importUsage)
 	}
 
 	payload, err := readImportPayload(source)
 	if err != nil {
-		return "", "", err
+		return "", "", false, err
 	}
 	if strings.TrimSpace(payload) == "" {
-		return "", "", errors.New("import payload is empty")
+		return "", "", false, errors.New("import payload is empty")
 	}
-	return payload, strategy, nil
+	return payload, strategy, strict, nil
 }
 
 func readImportPayload(source string) (string, error) {
@@ -302,15 +432,3 @@ func readImportPayload(source string) (string, error) {
 	}
 	return string(b), nil
 }
-
-func runDetached(path string, args []string) error {
-	cmd := exec.Command(path, args...)
-	cmd.Stdin = nil
-	cmd.Stdout = nil
-	cmd.Stderr = nil
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		CreationFlags: 0x00000008 | 0x08000000,
-		HideWindow:    true,
-	}
-	return cmd.Start()
-}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"wincron/internal/ipc"
+)
+
+const statsUsage = "usage: wincronctl stats <job name> [--from <date>] [--to <date>] [--json]"
+
+// jobStats mirrors the fields of the GUI's JobStats that are printed.
+type jobStats struct {
+	JobName              string         `json:"jobName"`
+	Runs                 int            `json:"runs"`
+	Succeeded            int            `json:"succeeded"`
+	Failed               int            `json:"failed"`
+	SuccessRate          float64        `json:"successRate"`
+	AvgDurationMs        int64          `json:"avgDurationMs"`
+	P50DurationMs        int64          `json:"p50DurationMs"`
+	P95DurationMs        int64          `json:"p95DurationMs"`
+	LongestFailureStreak int            `json:"longestFailureStreak"`
+	CurrentFailureStreak int            `json:"currentFailureStreak"`
+	ScheduledRuns        int            `json:"scheduledRuns"`
+	LateRuns             int            `json:"lateRuns"`
+	AvgLatenessMs        int64          `json:"avgLatenessMs"`
+	P95LatenessMs        int64          `json:"p95LatenessMs"`
+	ConsistentlyLate     bool           `json:"consistentlyLate"`
+	ByTriggerSource      map[string]int `json:"byTriggerSource"`
+	LongestRun           *struct {
+		StartedAt  string `json:"startedAt"`
+		DurationMs int64  `json:"durationMs"`
+	} `json:"longestRun"`
+	Daily []struct {
+		Date   string `json:"date"`
+		Runs   int    `json:"runs"`
+		Failed int    `json:"failed"`
+	} `json:"daily"`
+}
+
+func parseStatsArgs(args []string, req *ipc.Request) error {
+	var nameParts []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--from" || arg == "--to":
+			if i+1 >= len(args) {
+				return errors.New(statsUsage)
+			}
+			i++
+			if arg == "--from" {
+				req.From = args[i]
+			} else {
+				req.To = args[i]
+			}
+		case strings.HasPrefix(arg, "--from="):
+			req.From = strings.TrimPrefix(arg, "--from=")
+		case strings.HasPrefix(arg, "--to="):
+			req.To = strings.TrimPrefix(arg, "--to=")
+		case arg == "--json":
+			req.Format = "json"
+		case strings.HasPrefix(arg, "--"):
+			return fmt.Errorf("unknown stats option: %s", arg)
+		default:
+			nameParts = append(nameParts, arg)
+		}
+	}
+	req.Target = strings.Join(nameParts, " ")
+	if strings.TrimSpace(req.Target) == "" {
+		return errors.New(statsUsage)
+	}
+	return nil
+}
+
+func printStats(w io.Writer, data json.RawMessage, asJSON bool) error {
+	if asJSON {
+		_, err := fmt.Fprintln(w, string(data))
+		return err
+	}
+	var all []jobStats
+	if err := json.Unmarshal(data, &all); err != nil {
+		return err
+	}
+	for i, st := range all {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintln(w, st.JobName)
+		if st.Runs == 0 {
+			fmt.Fprintln(w, "  no runs")
+			continue
+		}
+		fmt.Fprintf(w, "  runs:      %d (%d succeeded, %d failed), success rate %.1f%%\n", st.Runs, st.Succeeded, st.Failed, st.SuccessRate*100)
+		fmt.Fprintf(w, "  duration:  avg %s, p50 %s, p95 %s\n", formatMs(st.AvgDurationMs), formatMs(st.P50DurationMs), formatMs(st.P95DurationMs))
+		if st.LongestRun != nil {
+			fmt.Fprintf(w, "  longest:   %s at %s\n", formatMs(st.LongestRun.DurationMs), st.LongestRun.StartedAt)
+		}
+		fmt.Fprintf(w, "  failures:  longest streak %d, current streak %d\n", st.LongestFailureStreak, st.CurrentFailureStreak)
+		if st.ScheduledRuns > 0 {
+			late := ""
+			if st.ConsistentlyLate {
+				late = " - consistently late"
+			}
+			fmt.Fprintf(w, "  lateness:  avg %s, p95 %s, %d of %d cron runs late%s\n",
+				formatMs(st.AvgLatenessMs), formatMs(st.P95LatenessMs), st.LateRuns, st.ScheduledRuns, late)
+		}
+
+		sources := make([]string, 0, len(st.ByTriggerSource))
+		for source := range st.ByTriggerSource {
+			sources = append(sources, source)
+		}
+		sort.Strings(sources)
+		parts := make([]string, 0, len(sources))
+		for _, source := range sources {
+			parts = append(parts, fmt.Sprintf("%s %d", source, st.ByTriggerSource[source]))
+		}
+		fmt.Fprintf(w, "  triggers:  %s\n", strings.Join(parts, ", "))
+
+		// Only the most recent days; --json has all of them.
+		days := st.Daily
+		if len(days) > 14 {
+			days = days[len(days)-14:]
+		}
+		for _, day := range days {
+			fmt.Fprintf(w, "  %s  %4d runs  %4d failed\n", day.Date, day.Runs, day.Failed)
+		}
+	}
+	return nil
+}
+
+func formatMs(ms int64) string {
+	return (time.Duration(ms) * time.Millisecond).Round(time.Millisecond).String()
+}
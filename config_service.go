@@ -156,6 +156,16 @@ func (s *ConfigService) CheckImportYAMLConflicts(yamlText string) ([]string, err
 }
 
 func (s *ConfigService) ImportYAML(yamlText string, conflictStrategy string) error {
+	return s.importYAML(yamlText, conflictStrategy, false)
+}
+
+// ImportYAMLStrict validates every job with ValidateJob first and imports
+// nothing when any of them has errors.
+func (s *ConfigService) ImportYAMLStrict(yamlText string, conflictStrategy string) error {
+	return s.importYAML(yamlText, conflictStrategy, true)
+}
+
+func (s *ConfigService) importYAML(yamlText string, conflictStrategy string, strict bool) error {
 	jobs, settings, err := parseYAMLConfig([]byte(yamlText))
 	if err != nil {
 		return err
@@ -186,6 +196,7 @@ func (s *ConfigService) ImportYAML(yamlText string, conflictStrategy string) err
 		reservedNames[name] = struct{}{}
 	}
 
+	prepared := make([]Job, 0, len(jobs))
 	for _, raw := range jobs {
 		job := raw
 		job.ConsecutiveFailures = 0
@@ -207,14 +218,37 @@ func (s *ConfigService) ImportYAML(yamlText string, conflictStrategy string) err
 			}
 		}
 
-		if _, err := s.cron.UpsertJob(job); err != nil {
-			return err
-		}
-
 		finalName := strings.TrimSpace(job.Name)
 		if finalName != "" {
 			reservedNames[finalName] = struct{}{}
 		}
+		prepared = append(prepared, job)
+	}
+
+	if strict {
+		var errs []error
+		for i, job := range prepared {
+			v, err := s.cron.ValidateJob(job)
+			if err != nil {
+				return err
+			}
+			name := strings.TrimSpace(job.Name)
+			if name == "" {
+				name = fmt.Sprintf("job #%d", i+1)
+			}
+			if err := validationError(name, v.strict()); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if len(errs) > 0 {
+			return errors.Join(errs...)
+		}
+	}
+
+	for _, job := range prepared {
+		if _, err := s.cron.UpsertJob(job); err != nil {
+			return err
+		}
 	}
 
 	if settings != nil {
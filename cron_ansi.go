@@ -0,0 +1,277 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	ansiModeKeep  = ""
+	ansiModeStrip = "strip"
+	ansiModeSpans = "spans"
+)
+
+// OutputSpan styles the runes [Start, End) of a plain-text output. Offsets
+// count Unicode code points, not bytes.
+type OutputSpan struct {
+	Start     int    `json:"start"`
+	End       int    `json:"end"`
+	Fg        string `json:"fg,omitempty"`
+	Bg        string `json:"bg,omitempty"`
+	Bold      bool   `json:"bold,omitempty"`
+	Dim       bool   `json:"dim,omitempty"`
+	Italic    bool   `json:"italic,omitempty"`
+	Underline bool   `json:"underline,omitempty"`
+	Inverse   bool   `json:"inverse,omitempty"`
+}
+
+type ansiStyle struct {
+	fg        string
+	bg        string
+	bold      bool
+	dim       bool
+	italic    bool
+	underline bool
+	inverse   bool
+}
+
+var ansiColorNames = [8]string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+func normalizeANSIMode(value string) string {
+	v := strings.ToLower(strings.TrimSpace(value))
+	if v == ansiModeStrip || v == ansiModeSpans {
+		return v
+	}
+	return ansiModeKeep
+}
+
+// processANSI applies the job's ANSI mode to decoded output. Escape sequences
+// are removed in both strip and spans mode; spans mode also returns the SGR
+// styling as runs over the plain text.
+func processANSI(text string, mode string) (string, []OutputSpan) {
+	mode = normalizeANSIMode(mode)
+	if mode == ansiModeKeep || !strings.ContainsRune(text, '\x1b') {
+		return text, nil
+	}
+
+	var (
+		b        strings.Builder
+		spans    []OutputSpan
+		style    ansiStyle
+		runeIdx  int
+		runStart int
+	)
+	b.Grow(len(text))
+
+	flush := func() {
+		if mode != ansiModeSpans || runeIdx == runStart || style == (ansiStyle{}) {
+			runStart = runeIdx
+			return
+		}
+		spans = append(spans, OutputSpan{
+			Start:     runStart,
+			End:       runeIdx,
+			Fg:        style.fg,
+			Bg:        style.bg,
+			Bold:      style.bold,
+			Dim:       style.dim,
+			Italic:    style.italic,
+			Underline: style.underline,
+			Inverse:   style.inverse,
+		})
+		runStart = runeIdx
+	}
+
+	for i := 0; i < len(text); {
+		if text[i] != '\x1b' {
+			r, size := utf8.DecodeRuneInString(text[i:])
+			b.WriteRune(r)
+			runeIdx++
+			i += size
+			continue
+		}
+
+		seqEnd, params, final := scanANSISequence(text, i)
+		if final == 'm' {
+			flush()
+			style = applySGR(style, params)
+		}
+		i = seqEnd
+	}
+	flush()
+	return b.String(), spans
+}
+
+// scanANSISequence returns the end offset of the escape sequence starting at
+// text[start], plus the parameters and final byte when it is a CSI sequence.
+func scanANSISequence(text string, start int) (int, string, byte) {
+	i := start + 1
+	if i >= len(text) {
+		return i, "", 0
+	}
+	switch text[i] {
+	case '[':
+		i++
+		paramStart := i
+		for i < len(text) && (text[i] < 0x40 || text[i] > 0x7e) {
+			i++
+		}
+		if i >= len(text) {
+			return i, "", 0
+		}
+		return i + 1, text[paramStart:i], text[i]
+	case ']':
+		// OSC runs until BEL or ST (ESC \).
+		for i++; i < len(text); i++ {
+			if text[i] == '\a' {
+				return i + 1, "", 0
+			}
+			if text[i] == '\x1b' && i+1 < len(text) && text[i+1] == '\\' {
+				return i + 2, "", 0
+			}
+		}
+		return i, "", 0
+	default:
+		return i + 1, "", 0
+	}
+}
+
+func applySGR(style ansiStyle, params string) ansiStyle {
+	if params == "" {
+		return ansiStyle{}
+	}
+	codes := strings.Split(params, ";")
+	for i := 0; i < len(codes); i++ {
+		code, err := strconv.Atoi(codes[i])
+		if err != nil {
+			code = 0
+		}
+		switch {
+		case code == 0:
+			style = ansiStyle{}
+		case code == 1:
+			style.bold = true
+		case code == 2:
+			style.dim = true
+		case code == 3:
+			style.italic = true
+		case code == 4:
+			style.underline = true
+		case code == 7:
+			style.inverse = true
+		case code == 22:
+			style.bold, style.dim = false, false
+		case code == 23:
+			style.italic = false
+		case code == 24:
+			style.underline = false
+		case code == 27:
+			style.inverse = false
+		case code >= 30 && code <= 37:
+			style.fg = ansiColorNames[code-30]
+		case code >= 90 && code <= 97:
+			style.fg = "bright-" + ansiColorNames[code-90]
+		case code == 39:
+			style.fg = ""
+		case code >= 40 && code <= 47:
+			style.bg = ansiColorNames[code-40]
+		case code >= 100 && code <= 107:
+			style.bg = "bright-" + ansiColorNames[code-100]
+		case code == 49:
+			style.bg = ""
+		case code == 38 || code == 48:
+			color, used := parseExtendedColor(codes[i+1:])
+			i += used
+			if code == 38 {
+				style.fg = color
+			} else {
+				style.bg = color
+			}
+		}
+	}
+	return style
+}
+
+// parseExtendedColor reads the arguments after 38/48: "5;n" for the 256
+// color palette or "2;r;g;b" for true color.
+func parseExtendedColor(args []string) (string, int) {
+	if len(args) == 0 {
+		return "", 0
+	}
+	switch args[0] {
+	case "5":
+		if len(args) < 2 {
+			return "", len(args)
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 0 || n > 255 {
+			return "", 2
+		}
+		if n < 8 {
+			return ansiColorNames[n], 2
+		}
+		if n < 16 {
+			return "bright-" + ansiColorNames[n-8], 2
+		}
+		return "ansi-" + strconv.Itoa(n), 2
+	case "2":
+		if len(args) < 4 {
+			return "", len(args)
+		}
+		var rgb [3]int
+		for k := 0; k < 3; k++ {
+			v, err := strconv.Atoi(args[k+1])
+			if err != nil || v < 0 || v > 255 {
+				return "", 4
+			}
+			rgb[k] = v
+		}
+		return fmt.Sprintf("#%02x%02x%02x", rgb[0], rgb[1], rgb[2]), 4
+	default:
+		return "", 1
+	}
+}
+
+// clipOutputSpans drops styling beyond the first max runes, matching
+// truncateString.
+func clipOutputSpans(spans []OutputSpan, max int) []OutputSpan {
+	if len(spans) == 0 {
+		return spans
+	}
+	clipped := spans[:0:0]
+	for _, span := range spans {
+		if span.Start >= max {
+			break
+		}
+		if span.End > max {
+			span.End = max
+		}
+		clipped = append(clipped, span)
+	}
+	return clipped
+}
+
+func encodeOutputSpans(spans []OutputSpan) string {
+	if len(spans) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(spans)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func decodeOutputSpans(value string) []OutputSpan {
+	if value == "" {
+		return nil
+	}
+	var spans []OutputSpan
+	if err := json.Unmarshal([]byte(value), &spans); err != nil {
+		return nil
+	}
+	return spans
+}
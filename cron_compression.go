@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+	"os"
+)
+
+// output_compression of job_logs and job_log_steps says how stdout and stderr
+// of a row are stored. Rows written before compression existed are plain.
+const (
+	logOutputPlain   = ""
+	logOutputDeflate = "deflate"
+)
+
+// Output shorter than this is stored as is; deflate gains little on it.
+const logCompressMinBytes = 512
+
+const logCompressBatch = 200
+
+// LogStorageStats reports how much space compression saves. OutputBytes is
+// the size of stdout and stderr of runs and their steps as written by the
+// jobs, StoredOutputBytes what they take in the database.
+type LogStorageStats struct {
+	Entries           int   `json:"entries"`
+	CompressedEntries int   `json:"compressedEntries"`
+	OutputBytes       int64 `json:"outputBytes"`
+	StoredOutputBytes int64 `json:"storedOutputBytes"`
+	SavedBytes        int64 `json:"savedBytes"`
+	FileBytes         int64 `json:"fileBytes"`
+}
+
+// encodeLogOutput returns the values to store for stdout and stderr, how they
+// are stored and their uncompressed size. Both are deflated, or neither.
+func encodeLogOutput(stdout string, stderr string) (any, any, string, int64) {
+	size := int64(len(stdout) + len(stderr))
+	if size < logCompressMinBytes {
+		return stdout, stderr, logOutputPlain, size
+	}
+	out, errOut := deflateString(stdout), deflateString(stderr)
+	if out == nil || errOut == nil || int64(len(out)+len(errOut)) >= size {
+		return stdout, stderr, logOutputPlain, size
+	}
+	return out, errOut, logOutputDeflate, size
+}
+
+func deflateString(s string) []byte {
+	var b bytes.Buffer
+	w, err := flate.NewWriter(&b, flate.DefaultCompression)
+	if err != nil {
+		return nil
+	}
+	if _, err := io.WriteString(w, s); err != nil {
+		return nil
+	}
+	if err := w.Close(); err != nil {
+		return nil
+	}
+	return b.Bytes()
+}
+
+// decodeLogOutput returns stored output as text. Output that cannot be
+// inflated reads as empty rather than failing the whole listing.
+func decodeLogOutput(value []byte, compression string) string {
+	if compression != logOutputDeflate {
+		return string(value)
+	}
+	out, err := io.ReadAll(flate.NewReader(bytes.NewReader(value)))
+	if err != nil {
+		return ""
+	}
+	return string(out)
+}
+
+// GetLogStorageStats reports the size of the stored output and of
+// logs.sqlite.
+func (s *CronService) GetLogStorageStats() (LogStorageStats, error) {
+	s.logsMu.Lock()
+	defer s.logsMu.Unlock()
+	return s.logs.storageStats()
+}
+
+// CompressLogs compresses the output of runs stored before compression
+// existed, then vacuums so the file shrinks. New runs are compressed when
+// they are stored.
+func (s *CronService) CompressLogs() (LogStorageStats, error) {
+	for _, table := range []string{"job_logs", "job_log_steps"} {
+		var afterRowid int64
+		for {
+			s.logsMu.Lock()
+			lastRowid, err := s.logs.compressBatch(table, afterRowid, logCompressBatch)
+			s.logsMu.Unlock()
+			if err != nil {
+				return LogStorageStats{}, err
+			}
+			if lastRowid == 0 {
+				break
+			}
+			afterRowid = lastRowid
+		}
+	}
+
+	s.logsMu.Lock()
+	defer s.logsMu.Unlock()
+	if err := s.logs.vacuum(); err != nil {
+		return LogStorageStats{}, err
+	}
+	return s.logs.storageStats()
+}
+
+func (s *logStore) storageStats() (LogStorageStats, error) {
+	if err := s.ensureInit(); err != nil {
+		return LogStorageStats{}, err
+	}
+	var st LogStorageStats
+	if err := s.db.QueryRow(`SELECT COUNT(1),
+			COALESCE(SUM(CASE WHEN output_compression <> '' THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(output_size), 0),
+			COALESCE(SUM(length(CAST(stdout AS BLOB)) + length(CAST(stderr AS BLOB))), 0)
+		FROM job_logs;`).Scan(&st.Entries, &st.CompressedEntries, &st.OutputBytes, &st.StoredOutputBytes); err != nil {
+		return LogStorageStats{}, err
+	}
+	var stepOutput, stepStored int64
+	if err := s.db.QueryRow(`SELECT COALESCE(SUM(output_size), 0),
+			COALESCE(SUM(length(CAST(stdout AS BLOB)) + length(CAST(stderr AS BLOB))), 0)
+		FROM job_log_steps;`).Scan(&stepOutput, &stepStored); err != nil {
+		return LogStorageStats{}, err
+	}
+	st.OutputBytes += stepOutput
+	st.StoredOutputBytes += stepStored
+	st.SavedBytes = st.OutputBytes - st.StoredOutputBytes
+	for _, path := range []string{s.path, s.path + "-wal"} {
+		if info, err := os.Stat(path); err == nil {
+			st.FileBytes += info.Size()
+		}
+	}
+	return st, nil
+}
+
+// compressBatch compresses the plain output of up to limit finished rows of
+// table, job_logs or job_log_steps, after afterRowid and returns the last
+// rowid it looked at, or 0 when there were none left.
+func (s *logStore) compressBatch(table string, afterRowid int64, limit int) (int64, error) {
+	if err := s.ensureInit(); err != nil {
+		return 0, err
+	}
+	rows, err := s.db.Query(`SELECT rowid, stdout, stderr FROM `+table+`
+		WHERE output_compression = '' AND output_size >= ? AND status <> 'running' AND rowid > ?
+		ORDER BY rowid
+		LIMIT ?;`, logCompressMinBytes, afterRowid, limit)
+	if err != nil {
+		return 0, err
+	}
+	type plainOutput struct {
+		rowid          int64
+		stdout, stderr string
+	}
+	var batch []plainOutput
+	for rows.Next() {
+		var p plainOutput
+		if err := rows.Scan(&p.rowid, &p.stdout, &p.stderr); err != nil {
+			_ = rows.Close()
+			return 0, err
+		}
+		batch = append(batch, p)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(batch) == 0 {
+		return 0, nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	for _, p := range batch {
+		stdout, stderr, compression, _ := encodeLogOutput(p.stdout, p.stderr)
+		if compression == logOutputPlain {
+			continue
+		}
+		if _, err := tx.Exec(`UPDATE `+table+` SET stdout = ?, stderr = ?, output_compression = ? WHERE rowid = ?;`,
+			stdout, stderr, compression, p.rowid); err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return batch[len(batch)-1].rowid, nil
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncodeLogOutputRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		stdout string
+		stderr string
+		want   string
+	}{
+		{"short stays plain", "ok", "", logOutputPlain},
+		{"repetitive is deflated", strings.Repeat("copying file\n", 100), "", logOutputDeflate},
+		{"both streams together", strings.Repeat("a", 300), strings.Repeat("b", 300), logOutputDeflate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr, compression, size := encodeLogOutput(tt.stdout, tt.stderr)
+			if compression != tt.want {
+				t.Fatalf("compression = %q, want %q", compression, tt.want)
+			}
+			if size != int64(len(tt.stdout)+len(tt.stderr)) {
+				t.Errorf("size = %d", size)
+			}
+			if got := decodeLogOutput(storedBytes(stdout), compression); got != tt.stdout {
+				t.Errorf("stdout round trip = %q", got)
+			}
+			if got := decodeLogOutput(storedBytes(stderr), compression); got != tt.stderr {
+				t.Errorf("stderr round trip = %q", got)
+			}
+		})
+	}
+}
+
+func storedBytes(v any) []byte {
+	if s, ok := v.(string); ok {
+		return []byte(s)
+	}
+	return v.([]byte)
+}
+
+func TestCompressedOutputIsSearchable(t *testing.T) {
+	s := newTestLogStore(t)
+	start := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	long := strings.Repeat("robocopy: copied file\n", 60) + "ERROR 5 access denied to backup share\n"
+
+	running := testLogEntry("r1", "a", start, logStatusRunning)
+	if err := s.append(running); err != nil {
+		t.Fatal(err)
+	}
+	done := testLogEntry("r1", "a", start, logStatusFailed)
+	done.Stdout = long
+	done.Steps = []JobStepResult{{Index: 0, Name: "copy", Status: logStatusFailed, Stdout: long}}
+	if err := s.append(done); err != nil {
+		t.Fatal(err)
+	}
+	plain := testLogEntry("r2", "a", start.Add(time.Minute), logStatusSuccess)
+	plain.Stdout = "access granted"
+	if err := s.append(plain); err != nil {
+		t.Fatal(err)
+	}
+
+	var compression string
+	if err := s.db.QueryRow(`SELECT output_compression FROM job_logs WHERE id = 'r1';`).Scan(&compression); err != nil {
+		t.Fatal(err)
+	}
+	if compression != logOutputDeflate {
+		t.Fatalf("run output stored as %q", compression)
+	}
+	if err := s.db.QueryRow(`SELECT output_compression FROM job_log_steps WHERE entry_id = 'r1';`).Scan(&compression); err != nil {
+		t.Fatal(err)
+	}
+	if compression != logOutputDeflate {
+		t.Fatalf("step output stored as %q", compression)
+	}
+
+	check := func(step string) {
+		t.Helper()
+		result, err := s.search(logFilter{match: ftsMatchQuery(`"access denied"`)}, 0, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Items) != 1 {
+			t.Fatalf("%s: %d hits, want 1", step, len(result.Items))
+		}
+		hit := result.Items[0]
+		if hit.Entry.ID != "r1" || hit.Entry.Stdout != long || hit.Entry.Steps[0].Stdout != long {
+			t.Errorf("%s: hit does not carry the decompressed output", step)
+		}
+		if !strings.Contains(hit.Snippet, "access denied") || len(hit.Highlights) != 1 {
+			t.Errorf("%s: snippet %q, highlights %v", step, hit.Snippet, hit.Highlights)
+		}
+	}
+	check("after append")
+
+	stats, err := s.storageStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantOutput := int64(2*len(long) + len(plain.Stdout))
+	if stats.OutputBytes != wantOutput || stats.StoredOutputBytes >= stats.OutputBytes || stats.CompressedEntries != 1 {
+		t.Errorf("stats = %+v, want %d output bytes with savings", stats, wantOutput)
+	}
+
+	if err := s.vacuum(); err != nil {
+		t.Fatal(err)
+	}
+	check("after vacuum")
+	// VACUUM is free to renumber the rowids, though it seldom does.
+	if _, err := s.db.Exec(`UPDATE job_logs SET rowid = rowid + 100;`); err != nil {
+		t.Fatal(err)
+	}
+	check("after renumbering")
+	later := testLogEntry("r3", "a", start.Add(2*time.Minute), logStatusSuccess)
+	later.Stdout = "no match here"
+	if err := s.append(later); err != nil {
+		t.Fatal(err)
+	}
+	check("after appending past the vacuum")
+
+	if err := s.deleteEntry("r1"); err != nil {
+		t.Fatal(err)
+	}
+	result, err := s.search(logFilter{match: ftsMatchQuery("access")}, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Items) != 1 || result.Items[0].Entry.ID != "r2" {
+		t.Errorf("search after delete = %+v", result.Items)
+	}
+
+	var udfs int
+	if err := s.db.QueryRow(`SELECT COUNT(1) FROM sqlite_master WHERE sql LIKE '%wincron_%';`).Scan(&udfs); err != nil {
+		t.Fatal(err)
+	}
+	if udfs != 0 {
+		t.Error("the schema calls functions only wincron provides")
+	}
+}
+
+func TestCompressLogsCompressesPlainRows(t *testing.T) {
+	s := newTestLogStore(t)
+	long := strings.Repeat("line of build output\n", 50)
+	entry := testLogEntry("old", "a", time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC), logStatusSuccess)
+	entry.Stdout = "needle"
+	entry.Steps = []JobStepResult{{Index: 0, Name: "build", Status: logStatusSuccess}}
+	if err := s.append(entry); err != nil {
+		t.Fatal(err)
+	}
+	// Rows from before compression keep their output plain.
+	for _, q := range []string{
+		`UPDATE job_logs SET stdout = ?, output_size = length(?) WHERE id = 'old';`,
+		`UPDATE job_log_steps SET stdout = ?, output_size = length(?) WHERE entry_id = 'old';`,
+	} {
+		if _, err := s.db.Exec(q, long+"needle", long+"needle"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rebuildLogSearch(s.db); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, table := range []string{"job_logs", "job_log_steps"} {
+		last, err := s.compressBatch(table, 0, logCompressBatch)
+		if err != nil || last == 0 {
+			t.Fatalf("compress %s: last rowid %d, %v", table, last, err)
+		}
+		if last, err = s.compressBatch(table, last, logCompressBatch); err != nil || last != 0 {
+			t.Fatalf("compress %s again: last rowid %d, %v", table, last, err)
+		}
+		var compression string
+		if err := s.db.QueryRow(`SELECT output_compression FROM ` + table + ` LIMIT 1;`).Scan(&compression); err != nil {
+			t.Fatal(err)
+		}
+		if compression != logOutputDeflate {
+			t.Errorf("%s stored as %q", table, compression)
+		}
+	}
+
+	logs, err := s.tail("a", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 1 || logs[0].Stdout != long+"needle" || logs[0].Steps[0].Stdout != long+"needle" {
+		t.Fatal("compressed output does not read back")
+	}
+	result, err := s.search(logFilter{match: ftsMatchQuery("needle")}, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Items) != 1 {
+		t.Errorf("search after compression found %d runs", len(result.Items))
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+const outputEncodingAuto = "auto"
+
+var outputEncodings = map[string]encoding.Encoding{
+	"utf-8":        encoding.Nop,
+	"utf-16le":     unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM),
+	"utf-16be":     unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM),
+	"gbk":          simplifiedchinese.GBK,
+	"gb18030":      simplifiedchinese.GB18030,
+	"big5":         traditionalchinese.Big5,
+	"shift_jis":    japanese.ShiftJIS,
+	"euc-kr":       korean.EUCKR,
+	"cp437":        charmap.CodePage437,
+	"cp850":        charmap.CodePage850,
+	"cp852":        charmap.CodePage852,
+	"cp866":        charmap.CodePage866,
+	"windows-1250": charmap.Windows1250,
+	"windows-1251": charmap.Windows1251,
+	"windows-1252": charmap.Windows1252,
+}
+
+var outputEncodingAliases = map[string]string{
+	"utf8":      "utf-8",
+	"utf16":     "utf-16le",
+	"utf-16":    "utf-16le",
+	"utf16le":   "utf-16le",
+	"utf16be":   "utf-16be",
+	"cp936":     "gbk",
+	"cp950":     "big5",
+	"cp932":     "shift_jis",
+	"sjis":      "shift_jis",
+	"cp949":     "euc-kr",
+	"cp1250":    "windows-1250",
+	"cp1251":    "windows-1251",
+	"cp1252":    "windows-1252",
+	"ibm437":    "cp437",
+	"ibm850":    "cp850",
+	"ibm852":    "cp852",
+	"ibm866":    "cp866",
+	"shift-jis": "shift_jis",
+}
+
+// codePageEncodings maps Windows code page numbers to their encoding names.
+var codePageEncodings = map[uint32]string{
+	437:   "cp437",
+	850:   "cp850",
+	852:   "cp852",
+	866:   "cp866",
+	932:   "shift_jis",
+	936:   "gbk",
+	949:   "euc-kr",
+	950:   "big5",
+	1250:  "windows-1250",
+	1251:  "windows-1251",
+	1252:  "windows-1252",
+	1200:  "utf-16le",
+	1201:  "utf-16be",
+	65001: "utf-8",
+	54936: "gb18030",
+}
+
+// normalizeOutputEncoding returns the canonical encoding name, "" for auto,
+// or false when the name is unknown.
+func normalizeOutputEncoding(value string) (string, bool) {
+	v := strings.ToLower(strings.TrimSpace(value))
+	if v == "" || v == outputEncodingAuto {
+		return "", true
+	}
+	if alias, ok := outputEncodingAliases[v]; ok {
+		v = alias
+	}
+	if _, ok := outputEncodings[v]; ok {
+		return v, true
+	}
+	return "", false
+}
+
+// decodeOutput converts captured process output to UTF-8. With an empty
+// encoding it detects BOMs and BOM-less UTF-16, keeps valid UTF-8, and
+// otherwise decodes with fallback (usually the OEM code page).
+func decodeOutput(raw []byte, name string, fallback string) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	if name == "" {
+		name = detectOutputEncoding(raw, fallback)
+	}
+	raw = trimOutputBOM(raw, name)
+	enc, ok := outputEncodings[name]
+	if !ok || enc == encoding.Nop {
+		return strings.ToValidUTF8(string(raw), "�")
+	}
+	decoded, err := enc.NewDecoder().Bytes(raw)
+	if err != nil {
+		return strings.ToValidUTF8(string(raw), "�")
+	}
+	return string(decoded)
+}
+
+func detectOutputEncoding(raw []byte, fallback string) string {
+	switch {
+	case bytes.HasPrefix(raw, []byte{0xEF, 0xBB, 0xBF}):
+		return "utf-8"
+	case bytes.HasPrefix(raw, []byte{0xFF, 0xFE}):
+		return "utf-16le"
+	case bytes.HasPrefix(raw, []byte{0xFE, 0xFF}):
+		return "utf-16be"
+	}
+	if enc := guessUTF16(raw); enc != "" {
+		return enc
+	}
+	if utf8.Valid(raw) {
+		return "utf-8"
+	}
+	if _, ok := outputEncodings[fallback]; ok {
+		return fallback
+	}
+	return "utf-8"
+}
+
+// guessUTF16 recognizes BOM-less UTF-16 by its zero bytes: mostly-ASCII text
+// has a zero in every other byte, which UTF-8 and legacy code pages never do.
+func guessUTF16(raw []byte) string {
+	if len(raw) < 4 || len(raw)%2 != 0 {
+		return ""
+	}
+	evenZeros, oddZeros := 0, 0
+	for i := 0; i+1 < len(raw); i += 2 {
+		if raw[i] == 0 {
+			evenZeros++
+		}
+		if raw[i+1] == 0 {
+			oddZeros++
+		}
+	}
+	pairs := len(raw) / 2
+	switch {
+	case oddZeros*10 >= pairs*6 && evenZeros*10 < pairs:
+		return "utf-16le"
+	case evenZeros*10 >= pairs*6 && oddZeros*10 < pairs:
+		return "utf-16be"
+	}
+	return ""
+}
+
+func trimOutputBOM(raw []byte, name string) []byte {
+	switch name {
+	case "utf-8":
+		return bytes.TrimPrefix(raw, []byte{0xEF, 0xBB, 0xBF})
+	case "utf-16le":
+		return bytes.TrimPrefix(raw, []byte{0xFF, 0xFE})
+	case "utf-16be":
+		return bytes.TrimPrefix(raw, []byte{0xFE, 0xFF})
+	}
+	return raw
+}
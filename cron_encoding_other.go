@@ -0,0 +1,7 @@
+//go:build !windows
+
+package main
+
+func systemOutputEncoding() string {
+	return "utf-8"
+}
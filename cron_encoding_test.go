@@ -0,0 +1,53 @@
+package main
+
+import "testing"
+
+func TestDecodeOutput(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      []byte
+		encoding string
+		fallback string
+		want     string
+	}{
+		{"empty", nil, "", "gbk", ""},
+		{"utf-8", []byte("héllo"), "", "gbk", "héllo"},
+		{"utf-8 bom", []byte("\xEF\xBB\xBFok"), "", "gbk", "ok"},
+		{"utf-16le bom", []byte{0xFF, 0xFE, 'o', 0, 'k', 0}, "", "gbk", "ok"},
+		{"utf-16be bom", []byte{0xFE, 0xFF, 0, 'o', 0, 'k'}, "", "gbk", "ok"},
+		{"utf-16le no bom", []byte{'d', 0, 'o', 0, 'n', 0, 'e', 0}, "", "gbk", "done"},
+		{"utf-16be no bom", []byte{0, 'd', 0, 'o', 0, 'n', 0, 'e'}, "", "gbk", "done"},
+		{"utf-16le explicit", []byte{0xFF, 0xFE, 0x2D, 0x4E}, "utf-16le", "", "中"},
+		{"gbk fallback", []byte{0xD6, 0xD0, 0xCE, 0xC4}, "", "gbk", "中文"},
+		{"gbk explicit", []byte{0xD6, 0xD0, 0xCE, 0xC4}, "gbk", "utf-8", "中文"},
+		{"invalid utf-8", []byte{'a', 0xFF, 'b'}, "utf-8", "", "a�b"},
+		{"invalid with unknown fallback", []byte{'a', 0xFF, 'b'}, "", "nope", "a�b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decodeOutput(tt.raw, tt.encoding, tt.fallback); got != tt.want {
+				t.Errorf("decodeOutput(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeOutputEncoding(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+		ok    bool
+	}{
+		{"", "", true},
+		{"Auto", "", true},
+		{" CP936 ", "gbk", true},
+		{"utf16", "utf-16le", true},
+		{"latin-9", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := normalizeOutputEncoding(tt.value)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("normalizeOutputEncoding(%q) = %q, %v; want %q, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
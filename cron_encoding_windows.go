@@ -0,0 +1,10 @@
+package main
+
+var procGetOEMCP = kernel32.NewProc("GetOEMCP")
+
+// systemOutputEncoding is the OEM code page console programs write in when
+// their output is redirected.
+func systemOutputEncoding() string {
+	cp, _, _ := procGetOEMCP.Call()
+	return codePageEncodings[uint32(cp)]
+}
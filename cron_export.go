@@ -0,0 +1,390 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LogExportFilter selects the runs to export. Empty JobIDs means all jobs;
+// From and To take the same values as LogSearchQuery.
+type LogExportFilter struct {
+	JobIDs []string `json:"jobIds"`
+	Status string   `json:"status"`
+	From   string   `json:"from"`
+	To     string   `json:"to"`
+}
+
+type LogExportResult struct {
+	Path   string `json:"path"`
+	Format string `json:"format"`
+	Count  int    `json:"count"`
+}
+
+const (
+	logExportCSV   = "csv"
+	logExportJSONL = "jsonl"
+	logExportHTML  = "html"
+)
+
+// Runs are read in chunks so appends are not held up by a long export.
+const logExportChunk = 500
+
+var logExportCSVHeader = []string{
+	"id", "job_id", "job_name", "trigger_source", "status", "error_code", "exit_code",
+	"scheduled_at", "lateness_ms", "started_at", "finished_at", "duration_ms", "command_line", "error", "stdout", "stderr",
+}
+
+// normalizeLogExportFormat returns the format to write, inferring it from the
+// file extension when format is empty.
+func normalizeLogExportFormat(format string, path string) (string, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+	switch format {
+	case "csv":
+		return logExportCSV, nil
+	case "jsonl", "ndjson", "json":
+		return logExportJSONL, nil
+	case "html", "htm":
+		return logExportHTML, nil
+	case "":
+		return "", errors.New("format is required")
+	default:
+		return "", fmt.Errorf("unknown export format: %s", format)
+	}
+}
+
+// ExportLogs writes the matching runs, oldest first, to path. The file is
+// written next to path and renamed into place, so a failed export leaves no
+// partial file behind.
+func (s *CronService) ExportLogs(filter LogExportFilter, format string, path string) (LogExportResult, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return LogExportResult{}, errors.New("path is required")
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	format, err := normalizeLogExportFormat(format, path)
+	if err != nil {
+		return LogExportResult{}, err
+	}
+	if filepath.Ext(path) == "" {
+		path += "." + format
+	}
+
+	f, jobIDs, err := newLogExportFilter(filter)
+	if err != nil {
+		return LogExportResult{}, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return LogExportResult{}, err
+	}
+
+	tmp := path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return LogExportResult{}, err
+	}
+	w := bufio.NewWriter(file)
+	count, err := s.writeLogExport(w, format, f, jobIDs)
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return LogExportResult{}, err
+	}
+	return LogExportResult{Path: path, Format: format, Count: count}, nil
+}
+
+func newLogExportFilter(filter LogExportFilter) (logFilter, []string, error) {
+	f := logFilter{status: filter.Status}
+	if v := strings.TrimSpace(filter.Status); v != "" && normalizeLogStatusFilter(v) == "" {
+		return logFilter{}, nil, fmt.Errorf("unknown status: %s", filter.Status)
+	}
+	var err error
+	if f.fromMs, err = parseLogSearchTime(filter.From, false); err != nil {
+		return logFilter{}, nil, err
+	}
+	if f.toMs, err = parseLogSearchTime(filter.To, true); err != nil {
+		return logFilter{}, nil, err
+	}
+	if f.fromMs > 0 && f.toMs > 0 && f.toMs < f.fromMs {
+		return logFilter{}, nil, errors.New("to is before from")
+	}
+	var jobIDs []string
+	for _, id := range filter.JobIDs {
+		if id = strings.TrimSpace(id); id != "" {
+			jobIDs = append(jobIDs, id)
+		}
+	}
+	return f, jobIDs, nil
+}
+
+func (s *CronService) writeLogExport(w io.Writer, format string, filter logFilter, jobIDs []string) (int, error) {
+	switch format {
+	case logExportCSV:
+		return s.writeLogExportCSV(w, filter, jobIDs)
+	case logExportJSONL:
+		return s.writeLogExportJSONL(w, filter, jobIDs)
+	default:
+		return s.writeLogExportHTML(w, filter, jobIDs)
+	}
+}
+
+// eachExportChunk calls fn with the matching runs in chunks, oldest first,
+// taking the log lock for each chunk only.
+func (s *CronService) eachExportChunk(filter logFilter, jobIDs []string, fn func([]JobLogEntry) error) (int, error) {
+	var (
+		afterMs int64
+		afterID string
+		count   int
+	)
+	for {
+		s.logsMu.Lock()
+		entries, err := s.logs.exportChunk(filter, jobIDs, afterMs, afterID, logExportChunk)
+		s.logsMu.Unlock()
+		if err != nil {
+			return count, err
+		}
+		if len(entries) == 0 {
+			return count, nil
+		}
+		if err := fn(entries); err != nil {
+			return count, err
+		}
+		count += len(entries)
+		if len(entries) < logExportChunk {
+			return count, nil
+		}
+		last := entries[len(entries)-1]
+		afterMs, afterID = parseRFC3339ToUnixMs(last.StartedAt), last.ID
+	}
+}
+
+func (s *CronService) writeLogExportCSV(w io.Writer, filter logFilter, jobIDs []string) (int, error) {
+	// The byte order mark makes Excel read the file as UTF-8.
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return 0, err
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(logExportCSVHeader); err != nil {
+		return 0, err
+	}
+	count, err := s.eachExportChunk(filter, jobIDs, func(entries []JobLogEntry) error {
+		for _, e := range entries {
+			if err := cw.Write([]string{
+				e.ID, e.JobID, e.JobName, e.TriggerSource, e.Status, e.ErrorCode, strconv.Itoa(e.ExitCode),
+				e.ScheduledAt, strconv.FormatInt(e.LatenessMs, 10), e.StartedAt, e.FinishedAt, strconv.FormatInt(e.DurationMs, 10), e.CommandLine, e.Error, e.Stdout, e.Stderr,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+	cw.Flush()
+	return count, cw.Error()
+}
+
+func (s *CronService) writeLogExportJSONL(w io.Writer, filter logFilter, jobIDs []string) (int, error) {
+	enc := json.NewEncoder(w)
+	return s.eachExportChunk(filter, jobIDs, func(entries []JobLogEntry) error {
+		for _, e := range entries {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// logExportJobSummary is one row of the summary table of an HTML report.
+type logExportJobSummary struct {
+	JobID      string
+	JobName    string
+	Runs       int
+	Succeeded  int
+	Failed     int
+	Skipped    int
+	DurationMs int64
+}
+
+func (s *CronService) writeLogExportHTML(w io.Writer, filter logFilter, jobIDs []string) (int, error) {
+	s.logsMu.Lock()
+	summary, err := s.logs.exportSummary(filter, jobIDs)
+	s.logsMu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	header := struct {
+		Generated string
+		From      string
+		To        string
+		Status    string
+		Jobs      []logExportJobSummary
+	}{
+		Generated: time.Now().Format(time.RFC3339),
+		Status:    normalizeLogStatusFilter(filter.status),
+		Jobs:      summary,
+	}
+	if filter.fromMs > 0 {
+		header.From = unixMsToRFC3339(filter.fromMs)
+	}
+	if filter.toMs > 0 {
+		header.To = unixMsToRFC3339(filter.toMs)
+	}
+	if err := logReportTemplate.ExecuteTemplate(w, "header", header); err != nil {
+		return 0, err
+	}
+	count, err := s.eachExportChunk(filter, jobIDs, func(entries []JobLogEntry) error {
+		for _, e := range entries {
+			if err := logReportTemplate.ExecuteTemplate(w, "run", e); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+	return count, logReportTemplate.ExecuteTemplate(w, "footer", count)
+}
+
+var logReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"duration": func(ms int64) string {
+		return (time.Duration(ms) * time.Millisecond).Round(time.Millisecond).String()
+	},
+}).Parse(`{{define "header"}}<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>WinCron run report</title>
+<style>
+body { font: 14px/1.4 "Segoe UI", sans-serif; margin: 24px; color: #1f2328; }
+h1 { font-size: 20px; margin: 0 0 4px; }
+h2 { font-size: 16px; margin: 24px 0 8px; }
+.meta { color: #59636e; margin: 0 0 16px; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #d1d9e0; padding: 4px 8px; text-align: left; vertical-align: top; }
+th { background: #f6f8fa; }
+td.num { text-align: right; }
+.status-success { color: #1a7f37; }
+.status-skipped, .status-running { color: #59636e; }
+.status-failed, .status-timeout, .status-killed, .status-start_failed, .status-interrupted { color: #cf222e; }
+details { margin: 2px 0; }
+pre { margin: 4px 0; padding: 6px; background: #f6f8fa; white-space: pre-wrap; word-break: break-all; max-height: 400px; overflow: auto; }
+</style>
+</head>
+<body>
+<h1>WinCron run report</h1>
+<p class="meta">Generated {{.Generated}}{{if .From}} · from {{.From}}{{end}}{{if .To}} · to {{.To}}{{end}}{{if .Status}} · status {{.Status}}{{end}}</p>
+<h2>Summary</h2>
+<table>
+<tr><th>Job</th><th>Runs</th><th>Succeeded</th><th>Failed</th><th>Skipped</th><th>Total duration</th></tr>
+{{range .Jobs}}<tr><td>{{.JobName}}</td><td class="num">{{.Runs}}</td><td class="num">{{.Succeeded}}</td><td class="num">{{.Failed}}</td><td class="num">{{.Skipped}}</td><td class="num">{{duration .DurationMs}}</td></tr>
+{{else}}<tr><td colspan="6">No runs</td></tr>
+{{end}}</table>
+<h2>Runs</h2>
+<table>
+<tr><th>Started</th><th>Job</th><th>Trigger</th><th>Status</th><th>Exit code</th><th>Duration</th><th>Details</th></tr>
+{{end}}{{define "run"}}<tr><td>{{.StartedAt}}</td><td>{{.JobName}}</td><td>{{.TriggerSource}}</td><td class="status-{{.Status}}">{{.Status}}{{if .ErrorCode}} ({{.ErrorCode}}){{end}}</td><td class="num">{{.ExitCode}}</td><td class="num">{{duration .DurationMs}}</td><td>
+<details><summary>{{.CommandLine}}</summary>{{if .Error}}<pre>{{.Error}}</pre>{{end}}{{if .Stdout}}<pre>{{.Stdout}}</pre>{{end}}{{if .Stderr}}<pre>{{.Stderr}}</pre>{{end}}</details>
+</td></tr>
+{{end}}{{define "footer"}}</table>
+<p class="meta">{{.}} run(s)</p>
+</body>
+</html>
+{{end}}`))
+
+func logExportWhere(filter logFilter, jobIDs []string) (string, []any) {
+	where, args := filter.where()
+	if len(jobIDs) > 0 {
+		where += ` AND job_id IN (` + strings.TrimRight(strings.Repeat("?,", len(jobIDs)), ",") + `)`
+		for _, id := range jobIDs {
+			args = append(args, id)
+		}
+	}
+	return where, args
+}
+
+// exportChunk returns up to limit runs after (afterMs, afterID), oldest
+// first, with their steps and matrix children.
+func (s *logStore) exportChunk(filter logFilter, jobIDs []string, afterMs int64, afterID string, limit int) ([]JobLogEntry, error) {
+	if err := s.ensureInit(); err != nil {
+		return nil, err
+	}
+	where, args := logExportWhere(filter, jobIDs)
+	if afterID != "" {
+		where += ` AND (started_at, id) > (?, ?)`
+		args = append(args, afterMs, afterID)
+	}
+	rows, err := s.db.Query(`SELECT `+logEntryColumns+`
+		FROM job_logs`+where+`
+		ORDER BY started_at ASC, id ASC
+		LIMIT ?;`, append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := scanLogEntries(rows)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.loadSteps(entries); err != nil {
+		return nil, err
+	}
+	if err := s.loadChildren(entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (s *logStore) exportSummary(filter logFilter, jobIDs []string) ([]logExportJobSummary, error) {
+	if err := s.ensureInit(); err != nil {
+		return nil, err
+	}
+	where, args := logExportWhere(filter, jobIDs)
+	rows, err := s.db.Query(`SELECT job_id, MAX(job_name), COUNT(1),
+			SUM(CASE WHEN status = 'success' THEN 1 ELSE 0 END),
+			SUM(CASE WHEN status IN ('success', 'skipped', 'running') THEN 0 ELSE 1 END),
+			SUM(CASE WHEN status = 'skipped' THEN 1 ELSE 0 END),
+			SUM(duration_ms)
+		FROM job_logs`+where+`
+		GROUP BY job_id
+		ORDER BY MAX(job_name) COLLATE NOCASE;`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var summary []logExportJobSummary
+	for rows.Next() {
+		var j logExportJobSummary
+		if err := rows.Scan(&j.JobID, &j.JobName, &j.Runs, &j.Succeeded, &j.Failed, &j.Skipped, &j.DurationMs); err != nil {
+			return nil, err
+		}
+		summary = append(summary, j)
+	}
+	return summary, rows.Err()
+}
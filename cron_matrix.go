@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JobMatrix fans one trigger out into a child run per item. Items come from
+// the inline list, or one per line from File when the list is empty.
+type JobMatrix struct {
+	Items       []string `json:"items,omitempty" yaml:"items,omitempty"`
+	File        string   `json:"file,omitempty" yaml:"file,omitempty"`
+	Parallelism int      `json:"parallelism,omitempty" yaml:"parallelism,omitempty"`
+}
+
+func normalizeJobMatrix(matrix *JobMatrix) (*JobMatrix, error) {
+	if matrix == nil {
+		return nil, nil
+	}
+	m := *matrix
+	items := make([]string, 0, len(m.Items))
+	for _, item := range m.Items {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	m.Items = nil
+	if len(items) > 0 {
+		m.Items = items
+	}
+	m.File = strings.TrimSpace(m.File)
+	if len(m.Items) == 0 && m.File == "" {
+		return nil, errors.New("matrix needs items or a file")
+	}
+	if m.Parallelism < 1 {
+		m.Parallelism = 1
+	}
+	return &m, nil
+}
+
+// matrixItems returns the items to run. Blank lines and lines starting with
+// # are skipped in a matrix file.
+func matrixItems(m *JobMatrix) ([]string, error) {
+	if len(m.Items) > 0 {
+		return m.Items, nil
+	}
+	f, err := os.Open(m.File)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var items []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		items = append(items, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("matrix file has no items: %s", m.File)
+	}
+	return items, nil
+}
+
+// executeMatrix runs one child per matrix item, at most Parallelism at a time,
+// and folds the children into entry. Children are stored as their own log
+// entries pointing at entry through ParentID.
+func (s *CronService) executeMatrix(job Job, runningInstanceID string, run runOptions, runCtx runContext, entry *JobLogEntry) {
+	items, err := matrixItems(job.Matrix)
+	if err != nil {
+		failProcessStart(entry, logErrorMatrixUnavailable, fmt.Errorf("matrix: %w", err))
+		return
+	}
+
+	start := time.Now()
+	runDone := make(chan struct{})
+	defer close(runDone)
+	s.updateRunningInstance(job.ID, runningInstanceID, func(inst *runningJobInstance) {
+		inst.entry = *entry
+		inst.done = runDone
+	})
+	s.startExecution(*entry)
+
+	children := make([]JobLogEntry, len(items))
+	sem := make(chan struct{}, job.Matrix.Parallelism)
+	var wg sync.WaitGroup
+	for i, item := range items {
+		sem <- struct{}{}
+		if s.runningStopReason(job.ID, runningInstanceID) != "" {
+			<-sem
+			children[i] = skippedMatrixChild(job, *entry, item)
+			continue
+		}
+		wg.Add(1)
+		go func(i int, item string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			children[i] = s.runMatrixChild(job, runningInstanceID, run, runCtx, *entry, item)
+		}(i, item)
+	}
+	wg.Wait()
+
+	var (
+		stdout strings.Builder
+		stderr strings.Builder
+		failed []JobLogEntry
+	)
+	for _, child := range children {
+		entry.UserCPUMs += child.UserCPUMs
+		entry.SystemCPUMs += child.SystemCPUMs
+		if child.PeakMemoryBytes > entry.PeakMemoryBytes {
+			entry.PeakMemoryBytes = child.PeakMemoryBytes
+		}
+		writeSectionOutput(&stdout, child.Item, child.Stdout)
+		writeSectionOutput(&stderr, child.Item, child.Stderr)
+		if child.Status != logStatusSuccess {
+			failed = append(failed, child)
+		}
+		s.logsMu.Lock()
+		_ = s.logs.append(child)
+		s.logsMu.Unlock()
+	}
+
+	entry.Status, entry.ErrorCode, entry.ExitCode = logStatusSuccess, "", 0
+	if reason := s.runningStopReason(job.ID, runningInstanceID); reason == logErrorTimeout {
+		entry.Status, entry.ErrorCode = logStatusTimeout, logErrorTimeout
+	} else if reason != "" {
+		entry.Status, entry.ErrorCode = logStatusKilled, reason
+	} else if len(failed) > 0 {
+		entry.Status, entry.ErrorCode = failed[0].Status, failed[0].ErrorCode
+	}
+	if len(failed) > 0 {
+		entry.ExitCode = failed[0].ExitCode
+		entry.Error = fmt.Sprintf("%d of %d items failed, first: %s", len(failed), len(children), failed[0].Item)
+	}
+	if entry.Status != logStatusSuccess && entry.ExitCode == 0 {
+		entry.ExitCode = -1
+	}
+
+	end := time.Now()
+	entry.FinishedAt = end.Format(time.RFC3339)
+	entry.DurationMs = end.Sub(start).Milliseconds()
+	entry.Stdout = truncateString(stdout.String(), 16*1024)
+	entry.Stderr = truncateString(stderr.String(), 16*1024)
+}
+
+func newMatrixChild(job Job, parent JobLogEntry, item string) JobLogEntry {
+	child := newRunningLogEntry(job, parent.TriggerSource, time.Now())
+	child.ParentID = parent.ID
+	child.Item = item
+	child.Overrides = parent.Overrides
+	return child
+}
+
+func skippedMatrixChild(job Job, parent JobLogEntry, item string) JobLogEntry {
+	child := newMatrixChild(job, parent, item)
+	child.FinishedAt = child.StartedAt
+	child.ExitCode = -1
+	child.Status = logStatusSkipped
+	return child
+}
+
+func (s *CronService) runMatrixChild(job Job, runningInstanceID string, run runOptions, runCtx runContext, parent JobLogEntry, item string) JobLogEntry {
+	child := newMatrixChild(job, parent, item)
+	runCtx.RunID = child.ID
+	runCtx.Item = item
+	s.runProcess(job, run.overrides, runCtx, &child, func(_ *exec.Cmd, stopper *processStopper, _ <-chan struct{}) {
+		s.updateRunningInstance(job.ID, runningInstanceID, func(inst *runningJobInstance) {
+			inst.children = append(inst.children, stopper)
+			if inst.stopReason != "" {
+				stopper.stop(inst.stopReason)
+			}
+		})
+	})
+	return child
+}
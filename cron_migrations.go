@@ -0,0 +1,244 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// sqlConn is what migrations and schema helpers need; *sql.DB and *sql.Tx
+// both provide it.
+type sqlConn interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// logMigrations bring logs.sqlite from PRAGMA user_version i to i+1. Append
+// new migrations; never change one that has shipped. Databases from before
+// versioning report version 0 and may already have some of the columns, so
+// the early migrations only add what is missing.
+var logMigrations = []func(tx *sql.Tx) error{
+	migrateLogsBase,
+	migrateLogsStatus,
+	migrateLogsMetrics,
+	migrateLogsRunDetails,
+	migrateLogsSteps,
+	migrateLogsSearch,
+	migrateLogsCounts,
+	migrateLogsSchedule,
+	migrateLogsCompression,
+}
+
+func logSchemaVersion(q sqlConn) (int, error) {
+	var version int
+	err := q.QueryRow(`PRAGMA user_version;`).Scan(&version)
+	return version, err
+}
+
+// migrateLogDB applies the pending migrations, each in its own transaction
+// together with the version bump. A database written by a newer wincron is
+// refused rather than written with an older schema in mind.
+func migrateLogDB(db *sql.DB) error {
+	version, err := logSchemaVersion(db)
+	if err != nil {
+		return err
+	}
+	if version > len(logMigrations) {
+		return fmt.Errorf("log db schema version %d is newer than this wincron supports (%d)", version, len(logMigrations))
+	}
+	for ; version < len(logMigrations); version++ {
+		if err := applyLogMigration(db, version); err != nil {
+			return fmt.Errorf("log db migration %d: %w", version+1, err)
+		}
+	}
+	return nil
+}
+
+func applyLogMigration(db *sql.DB, version int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	if err := logMigrations[version](tx); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d;`, version+1)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func execAll(q sqlConn, stmts ...string) error {
+	for _, stmt := range stmts {
+		if _, err := q.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func migrateLogsBase(tx *sql.Tx) error {
+	if err := execAll(tx, `CREATE TABLE IF NOT EXISTS job_logs (
+		id TEXT PRIMARY KEY,
+		job_id TEXT NOT NULL,
+		job_name TEXT NOT NULL,
+		trigger_source TEXT NOT NULL DEFAULT '',
+		command_line TEXT NOT NULL,
+		started_at INTEGER NOT NULL,
+		finished_at INTEGER NOT NULL,
+		exit_code INTEGER NOT NULL,
+		stdout TEXT NOT NULL,
+		stderr TEXT NOT NULL,
+		error TEXT NOT NULL
+	);`); err != nil {
+		return err
+	}
+	if err := addSQLiteColumnIfMissing(tx, "job_logs", "trigger_source", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	return execAll(tx, `CREATE INDEX IF NOT EXISTS idx_job_logs_job_id_started_at ON job_logs(job_id, started_at DESC);`)
+}
+
+func migrateLogsStatus(tx *sql.Tx) error {
+	for _, column := range []string{"status", "error_code", "stop_stage"} {
+		if err := addSQLiteColumnIfMissing(tx, "job_logs", column, "TEXT NOT NULL DEFAULT ''"); err != nil {
+			return err
+		}
+	}
+	return execAll(tx,
+		backfillLogStatusSQL,
+		`CREATE INDEX IF NOT EXISTS idx_job_logs_status_started_at ON job_logs(status, started_at DESC);`,
+	)
+}
+
+func migrateLogsMetrics(tx *sql.Tx) error {
+	for _, column := range []string{"duration_ms", "user_cpu_ms", "system_cpu_ms", "peak_memory_bytes"} {
+		if err := addSQLiteColumnIfMissing(tx, "job_logs", column, "INTEGER NOT NULL DEFAULT 0"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func migrateLogsRunDetails(tx *sql.Tx) error {
+	for _, column := range []string{"stdout_spans", "stderr_spans", "overrides", "parent_id", "item"} {
+		if err := addSQLiteColumnIfMissing(tx, "job_logs", column, "TEXT NOT NULL DEFAULT ''"); err != nil {
+			return err
+		}
+	}
+	return execAll(tx, `CREATE INDEX IF NOT EXISTS idx_job_logs_parent_id ON job_logs(parent_id);`)
+}
+
+func migrateLogsSteps(tx *sql.Tx) error {
+	return execAll(tx, `CREATE TABLE IF NOT EXISTS job_log_steps (
+		entry_id TEXT NOT NULL,
+		step_index INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		command_line TEXT NOT NULL,
+		started_at INTEGER NOT NULL,
+		finished_at INTEGER NOT NULL,
+		exit_code INTEGER NOT NULL,
+		status TEXT NOT NULL,
+		error_code TEXT NOT NULL DEFAULT '',
+		duration_ms INTEGER NOT NULL DEFAULT 0,
+		stdout TEXT NOT NULL,
+		stderr TEXT NOT NULL,
+		error TEXT NOT NULL,
+		PRIMARY KEY (entry_id, step_index)
+	);`)
+}
+
+// migrateLogsSearch indexed the output columns directly. migrateLogsCompression
+// replaces the index once those columns may hold compressed output.
+func migrateLogsSearch(tx *sql.Tx) error {
+	return execAll(tx,
+		`CREATE VIRTUAL TABLE IF NOT EXISTS job_logs_fts USING fts5(
+			command_line, stdout, stderr, error,
+			content = 'job_logs', content_rowid = 'rowid'
+		);`,
+		`CREATE TRIGGER IF NOT EXISTS job_logs_fts_insert AFTER INSERT ON job_logs BEGIN
+			INSERT INTO job_logs_fts(rowid, command_line, stdout, stderr, error)
+			VALUES (new.rowid, new.command_line, new.stdout, new.stderr, new.error);
+		END;`,
+		`CREATE TRIGGER IF NOT EXISTS job_logs_fts_delete AFTER DELETE ON job_logs BEGIN
+			INSERT INTO job_logs_fts(job_logs_fts, rowid, command_line, stdout, stderr, error)
+			VALUES ('delete', old.rowid, old.command_line, old.stdout, old.stderr, old.error);
+		END;`,
+		`CREATE TRIGGER IF NOT EXISTS job_logs_fts_update AFTER UPDATE ON job_logs BEGIN
+			INSERT INTO job_logs_fts(job_logs_fts, rowid, command_line, stdout, stderr, error)
+			VALUES ('delete', old.rowid, old.command_line, old.stdout, old.stderr, old.error);
+			INSERT INTO job_logs_fts(rowid, command_line, stdout, stderr, error)
+			VALUES (new.rowid, new.command_line, new.stdout, new.stderr, new.error);
+		END;`,
+		`INSERT INTO job_logs_fts(job_logs_fts) VALUES ('rebuild');`,
+	)
+}
+
+// migrateLogsCounts keeps the number of top-level runs per job and status in
+// job_log_counts, so listing does not have to count rows on every page.
+func migrateLogsCounts(tx *sql.Tx) error {
+	return execAll(tx,
+		`CREATE TABLE IF NOT EXISTS job_log_counts (
+			job_id TEXT NOT NULL,
+			status TEXT NOT NULL,
+			count INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (job_id, status)
+		) WITHOUT ROWID;`,
+		`CREATE TRIGGER IF NOT EXISTS job_log_counts_insert AFTER INSERT ON job_logs WHEN new.parent_id = '' BEGIN
+			INSERT INTO job_log_counts(job_id, status, count) VALUES (new.job_id, new.status, 1)
+			ON CONFLICT(job_id, status) DO UPDATE SET count = count + 1;
+		END;`,
+		`CREATE TRIGGER IF NOT EXISTS job_log_counts_delete AFTER DELETE ON job_logs WHEN old.parent_id = '' BEGIN
+			UPDATE job_log_counts SET count = count - 1 WHERE job_id = old.job_id AND status = old.status;
+			DELETE FROM job_log_counts WHERE job_id = old.job_id AND status = old.status AND count <= 0;
+		END;`,
+		`CREATE TRIGGER IF NOT EXISTS job_log_counts_update AFTER UPDATE OF job_id, status, parent_id ON job_logs BEGIN
+			UPDATE job_log_counts SET count = count - 1
+				WHERE old.parent_id = '' AND job_id = old.job_id AND status = old.status;
+			DELETE FROM job_log_counts WHERE job_id = old.job_id AND status = old.status AND count <= 0;
+			INSERT INTO job_log_counts(job_id, status, count) SELECT new.job_id, new.status, 1 WHERE new.parent_id = ''
+			ON CONFLICT(job_id, status) DO UPDATE SET count = count + 1;
+		END;`,
+		`DELETE FROM job_log_counts;`,
+		`INSERT INTO job_log_counts(job_id, status, count)
+			SELECT job_id, status, COUNT(1) FROM job_logs WHERE parent_id = '' GROUP BY job_id, status;`,
+		`CREATE INDEX IF NOT EXISTS idx_job_logs_started_at_id ON job_logs(started_at DESC, id DESC);`,
+	)
+}
+
+func migrateLogsSchedule(tx *sql.Tx) error {
+	for _, column := range []string{"scheduled_at", "lateness_ms"} {
+		if err := addSQLiteColumnIfMissing(tx, "job_logs", column, "INTEGER NOT NULL DEFAULT 0"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateLogsCompression lets the output of runs and steps be stored
+// deflated and moves the search index onto text wincron decompresses itself.
+// Existing rows stay plain.
+func migrateLogsCompression(tx *sql.Tx) error {
+	for _, table := range []string{"job_logs", "job_log_steps"} {
+		if err := addSQLiteColumnIfMissing(tx, table, "output_compression", "TEXT NOT NULL DEFAULT ''"); err != nil {
+			return err
+		}
+		if err := addSQLiteColumnIfMissing(tx, table, "output_size", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+			return err
+		}
+	}
+	if err := execAll(tx,
+		`UPDATE job_logs SET output_size = length(CAST(stdout AS BLOB)) + length(CAST(stderr AS BLOB));`,
+		`UPDATE job_log_steps SET output_size = length(CAST(stdout AS BLOB)) + length(CAST(stderr AS BLOB));`,
+		`DROP TRIGGER IF EXISTS job_logs_fts_insert;`,
+		`DROP TRIGGER IF EXISTS job_logs_fts_delete;`,
+		`DROP TRIGGER IF EXISTS job_logs_fts_update;`,
+		`DROP TABLE IF EXISTS job_logs_fts;`,
+	); err != nil {
+		return err
+	}
+	return initLogSearch(tx)
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeUnversionedLogDB creates a logs.sqlite the way wincron did before the
+// schema was versioned: no trigger_source, status or any later column.
+func writeUnversionedLogDB(t *testing.T, path string) {
+	t.Helper()
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	started := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC).UnixMilli()
+	if err := execAll(db,
+		`CREATE TABLE job_logs (
+			id TEXT PRIMARY KEY,
+			job_id TEXT NOT NULL,
+			job_name TEXT NOT NULL,
+			command_line TEXT NOT NULL,
+			started_at INTEGER NOT NULL,
+			finished_at INTEGER NOT NULL,
+			exit_code INTEGER NOT NULL,
+			stdout TEXT NOT NULL,
+			stderr TEXT NOT NULL,
+			error TEXT NOT NULL
+		);`,
+	); err != nil {
+		t.Fatal(err)
+	}
+	rows := []struct {
+		id       string
+		exitCode int
+		stdout   string
+		errText  string
+	}{
+		{"ok", 0, "backup complete", ""},
+		{"bad", 2, "disk quota exceeded", "exit status 2"},
+		{"slow", -1, "", "timeout after 30s"},
+	}
+	for i, r := range rows {
+		at := started + int64(i)*60_000
+		if _, err := db.Exec(`INSERT INTO job_logs VALUES (?, 'a', 'backup', 'backup.cmd', ?, ?, ?, ?, '', ?);`,
+			r.id, at, at+1000, r.exitCode, r.stdout, r.errText); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestMigrateUnversionedLogDB(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs.sqlite")
+	writeUnversionedLogDB(t, path)
+
+	s := newLogStore(path)
+	if s.initErr != nil {
+		t.Fatalf("open legacy db: %v", s.initErr)
+	}
+	defer s.db.Close()
+
+	version, err := logSchemaVersion(s.db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != len(logMigrations) {
+		t.Errorf("user_version = %d, want %d", version, len(logMigrations))
+	}
+
+	logs, err := s.tail("a", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][2]string{
+		"ok":   {logStatusSuccess, ""},
+		"bad":  {logStatusFailed, logErrorExitCode},
+		"slow": {logStatusTimeout, logErrorTimeout},
+	}
+	if len(logs) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(logs), len(want))
+	}
+	for _, l := range logs {
+		if got := [2]string{l.Status, l.ErrorCode}; got != want[l.ID] {
+			t.Errorf("%s: status, error code = %q, want %q", l.ID, got, want[l.ID])
+		}
+	}
+
+	result, err := s.search(logFilter{match: "quota"}, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Items) != 1 || result.Items[0].Entry.ID != "bad" {
+		t.Errorf("search after migration = %+v", result.Items)
+	}
+}
+
+func TestLogDBNewerSchemaIsRefused(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs.sqlite")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`PRAGMA user_version = 999;`); err != nil {
+		t.Fatal(err)
+	}
+	_ = db.Close()
+
+	s := newLogStore(path)
+	if s.initErr == nil {
+		_ = s.db.Close()
+		t.Fatal("opened a log db from a newer version")
+	}
+}
+
+func TestMergeTwice(t *testing.T) {
+	tmp := t.TempDir()
+	for _, key := range []string{"TMPDIR", "TMP", "TEMP"} {
+		t.Setenv(key, tmp)
+	}
+
+	s := newTestLogStore(t)
+	start := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	if err := s.append(testLogEntry("here", "a", start, logStatusSuccess)); err != nil {
+		t.Fatal(err)
+	}
+
+	otherPath := filepath.Join(t.TempDir(), "other.sqlite")
+	writeUnversionedLogDB(t, otherPath)
+
+	for i := 0; i < 2; i++ {
+		if err := s.merge(otherPath); err != nil {
+			t.Fatalf("merge %d: %v", i+1, err)
+		}
+	}
+
+	count, err := s.count(logFilter{jobID: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 4 {
+		t.Errorf("count after two merges = %d, want 4", count)
+	}
+	result, err := s.search(logFilter{match: ftsMatchQuery("quota")}, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Items) != 1 || result.Items[0].Entry.ID != "bad" {
+		t.Errorf("search over merged runs = %+v", result.Items)
+	}
+	leftovers, err := filepath.Glob(filepath.Join(tmp, "wincron-merge-*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leftovers) != 0 {
+		t.Errorf("merge left temporary copies: %v", leftovers)
+	}
+	if _, err := os.Stat(otherPath); err != nil {
+		t.Errorf("merge source is gone: %v", err)
+	}
+}
@@ -1,50 +1,98 @@
 package main
 
 type Job struct {
-	ID                     string   `json:"id" yaml:"id"`
-	Name                   string   `json:"name" yaml:"name"`
-	Folder                 string   `json:"folder,omitempty" yaml:"folder,omitempty"`
-	Cron                   string   `json:"cron" yaml:"cron"`
-	Command                string   `json:"command" yaml:"command"`
-	Args                   []string `json:"args" yaml:"args"`
-	WorkDir                string   `json:"workDir" yaml:"workDir"`
-	InheritEnv             *bool    `json:"inheritEnv,omitempty" yaml:"inheritEnv,omitempty"`
-	Hotkey                 string   `json:"hotkey,omitempty" yaml:"hotkey,omitempty"`
-	FlagProcessCreation    string   `json:"flagProcessCreation,omitempty" yaml:"flagProcessCreation,omitempty"`
-	Timeout                int      `json:"timeout,omitempty" yaml:"timeout,omitempty"`
-	ConcurrencyPolicy      string   `json:"concurrencyPolicy,omitempty" yaml:"concurrencyPolicy,omitempty"`
-	Enabled                bool     `json:"enabled" yaml:"enabled"`
-	RunAtStartup           bool     `json:"runAtStartup" yaml:"runAtStartup"`
-	MaxConsecutiveFailures int      `json:"maxConsecutiveFailures" yaml:"maxConsecutiveFailures"`
-	ConsecutiveFailures    int      `json:"consecutiveFailures" yaml:"consecutiveFailures"`
-	ExecutedCount          int      `json:"executedCount" yaml:"-"`
-	LastExecutedAt         string   `json:"lastExecutedAt" yaml:"-"`
-	NextRunAt              string   `json:"nextRunAt,omitempty" yaml:"-"`
+	ID                     string        `json:"id" yaml:"id"`
+	Name                   string        `json:"name" yaml:"name"`
+	Folder                 string        `json:"folder,omitempty" yaml:"folder,omitempty"`
+	Cron                   string        `json:"cron" yaml:"cron"`
+	Command                string        `json:"command" yaml:"command"`
+	Args                   []string      `json:"args" yaml:"args"`
+	Params                 []JobParam    `json:"params,omitempty" yaml:"params,omitempty"`
+	Steps                  []JobStep     `json:"steps,omitempty" yaml:"steps,omitempty"`
+	Matrix                 *JobMatrix    `json:"matrix,omitempty" yaml:"matrix,omitempty"`
+	Shell                  string        `json:"shell,omitempty" yaml:"shell,omitempty"`
+	Script                 string        `json:"script,omitempty" yaml:"script,omitempty"`
+	StdinSource            string        `json:"stdinSource,omitempty" yaml:"stdinSource,omitempty"`
+	Stdin                  string        `json:"stdin,omitempty" yaml:"stdin,omitempty"`
+	WorkDir                string        `json:"workDir" yaml:"workDir"`
+	InheritEnv             *bool         `json:"inheritEnv,omitempty" yaml:"inheritEnv,omitempty"`
+	Hotkey                 string        `json:"hotkey,omitempty" yaml:"hotkey,omitempty"`
+	FlagProcessCreation    string        `json:"flagProcessCreation,omitempty" yaml:"flagProcessCreation,omitempty"`
+	Timeout                int           `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	StopSignal             string        `json:"stopSignal,omitempty" yaml:"stopSignal,omitempty"`
+	StopGracePeriod        int           `json:"stopGracePeriod,omitempty" yaml:"stopGracePeriod,omitempty"`
+	OutputEncoding         string        `json:"outputEncoding,omitempty" yaml:"outputEncoding,omitempty"`
+	AnsiMode               string        `json:"ansiMode,omitempty" yaml:"ansiMode,omitempty"`
+	SuccessExitCodes       string        `json:"successExitCodes,omitempty" yaml:"successExitCodes,omitempty"`
+	FailIfOutputMatches    string        `json:"failIfOutputMatches,omitempty" yaml:"failIfOutputMatches,omitempty"`
+	SucceedIfOutputMatches string        `json:"succeedIfOutputMatches,omitempty" yaml:"succeedIfOutputMatches,omitempty"`
+	ConcurrencyPolicy      string        `json:"concurrencyPolicy,omitempty" yaml:"concurrencyPolicy,omitempty"`
+	LogRetention           *LogRetention `json:"logRetention,omitempty" yaml:"logRetention,omitempty"`
+	Enabled                bool          `json:"enabled" yaml:"enabled"`
+	RunAtStartup           bool          `json:"runAtStartup" yaml:"runAtStartup"`
+	MaxConsecutiveFailures int           `json:"maxConsecutiveFailures" yaml:"maxConsecutiveFailures"`
+	ConsecutiveFailures    int           `json:"consecutiveFailures" yaml:"consecutiveFailures"`
+	ExecutedCount          int           `json:"executedCount" yaml:"-"`
+	LastExecutedAt         string        `json:"lastExecutedAt" yaml:"-"`
+	NextRunAt              string        `json:"nextRunAt,omitempty" yaml:"-"`
 }
 
 type PreviewRunRequest struct {
-	Command             string   `json:"command"`
-	Args                []string `json:"args"`
-	WorkDir             string   `json:"workDir"`
-	InheritEnv          *bool    `json:"inheritEnv,omitempty"`
-	FlagProcessCreation string   `json:"flagProcessCreation,omitempty"`
-	Timeout             int      `json:"timeout"`
-	JobID               string   `json:"jobId"`
-	JobName             string   `json:"jobName"`
+	Command                string     `json:"command"`
+	Args                   []string   `json:"args"`
+	Params                 []JobParam `json:"params,omitempty"`
+	Steps                  []JobStep  `json:"steps,omitempty"`
+	Matrix                 *JobMatrix `json:"matrix,omitempty"`
+	Shell                  string     `json:"shell,omitempty"`
+	Script                 string     `json:"script,omitempty"`
+	StdinSource            string     `json:"stdinSource,omitempty"`
+	Stdin                  string     `json:"stdin,omitempty"`
+	WorkDir                string     `json:"workDir"`
+	InheritEnv             *bool      `json:"inheritEnv,omitempty"`
+	FlagProcessCreation    string     `json:"flagProcessCreation,omitempty"`
+	Timeout                int        `json:"timeout"`
+	StopSignal             string     `json:"stopSignal,omitempty"`
+	StopGracePeriod        int        `json:"stopGracePeriod,omitempty"`
+	OutputEncoding         string     `json:"outputEncoding,omitempty"`
+	AnsiMode               string     `json:"ansiMode,omitempty"`
+	SuccessExitCodes       string     `json:"successExitCodes,omitempty"`
+	FailIfOutputMatches    string     `json:"failIfOutputMatches,omitempty"`
+	SucceedIfOutputMatches string     `json:"succeedIfOutputMatches,omitempty"`
+	JobID                  string     `json:"jobId"`
+	JobName                string     `json:"jobName"`
 }
 
+// JobLogEntry is a stored run. ScheduledAt is the planned fire time of a
+// cron run and LatenessMs how long after it the run started; both are empty
+// for other triggers.
 type JobLogEntry struct {
-	ID            string `json:"id"`
-	JobID         string `json:"jobId"`
-	JobName       string `json:"jobName"`
-	TriggerSource string `json:"triggerSource"`
-	CommandLine   string `json:"commandLine"`
-	StartedAt     string `json:"startedAt"`
-	FinishedAt    string `json:"finishedAt"`
-	ExitCode      int    `json:"exitCode"`
-	Stdout        string `json:"stdout"`
-	Stderr        string `json:"stderr"`
-	Error         string `json:"error"`
+	ID              string          `json:"id"`
+	JobID           string          `json:"jobId"`
+	JobName         string          `json:"jobName"`
+	ParentID        string          `json:"parentId,omitempty"`
+	Item            string          `json:"item,omitempty"`
+	TriggerSource   string          `json:"triggerSource"`
+	CommandLine     string          `json:"commandLine"`
+	StartedAt       string          `json:"startedAt"`
+	ScheduledAt     string          `json:"scheduledAt,omitempty"`
+	LatenessMs      int64           `json:"latenessMs,omitempty"`
+	FinishedAt      string          `json:"finishedAt"`
+	ExitCode        int             `json:"exitCode"`
+	Status          string          `json:"status"`
+	ErrorCode       string          `json:"errorCode,omitempty"`
+	StopStage       string          `json:"stopStage,omitempty"`
+	DurationMs      int64           `json:"durationMs"`
+	UserCPUMs       int64           `json:"userCpuMs"`
+	SystemCPUMs     int64           `json:"systemCpuMs"`
+	PeakMemoryBytes int64           `json:"peakMemoryBytes"`
+	Stdout          string          `json:"stdout"`
+	Stderr          string          `json:"stderr"`
+	StdoutSpans     []OutputSpan    `json:"stdoutSpans,omitempty"`
+	StderrSpans     []OutputSpan    `json:"stderrSpans,omitempty"`
+	Overrides       *RunOverrides   `json:"overrides,omitempty"`
+	Steps           []JobStepResult `json:"steps,omitempty"`
+	Children        []JobLogEntry   `json:"children,omitempty"`
+	Error           string          `json:"error"`
 }
 
 type JobLogPage struct {
@@ -52,4 +100,6 @@ type JobLogPage struct {
 	StoredCount int           `json:"storedCount"`
 	TotalCount  int           `json:"totalCount"`
 	HasMore     bool          `json:"hasMore"`
+	// NextCursor continues the listing with ListLogsPageAfter.
+	NextCursor string `json:"nextCursor,omitempty"`
 }
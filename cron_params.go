@@ -0,0 +1,164 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	paramTypeString = "string"
+	paramTypeNumber = "number"
+	paramTypeBool   = "bool"
+)
+
+// JobParam declares a named input of a job. Values are substituted as
+// {{.Params.name}} in Args, WorkDir and override env values, and exported as
+// WINCRON_PARAM_<NAME>. Cron and hotkey runs use Default.
+type JobParam struct {
+	Name        string   `json:"name" yaml:"name"`
+	Type        string   `json:"type,omitempty" yaml:"type,omitempty"`
+	Default     string   `json:"default,omitempty" yaml:"default,omitempty"`
+	Choices     []string `json:"choices,omitempty" yaml:"choices,omitempty"`
+	Required    bool     `json:"required,omitempty" yaml:"required,omitempty"`
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+var paramNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func normalizeParamType(value string) string {
+	v := strings.ToLower(strings.TrimSpace(value))
+	switch v {
+	case "", "str", "text":
+		return paramTypeString
+	case "int", "float", "number":
+		return paramTypeNumber
+	case "bool", "boolean":
+		return paramTypeBool
+	}
+	return v
+}
+
+// normalizeJobParams validates the schema and canonicalizes types and
+// defaults.
+func normalizeJobParams(params []JobParam) ([]JobParam, error) {
+	if len(params) == 0 {
+		return nil, nil
+	}
+	seen := make(map[string]struct{}, len(params))
+	out := make([]JobParam, 0, len(params))
+	for _, p := range params {
+		p.Name = strings.TrimSpace(p.Name)
+		if !paramNamePattern.MatchString(p.Name) {
+			return nil, fmt.Errorf("invalid param name: %q", p.Name)
+		}
+		if _, ok := seen[p.Name]; ok {
+			return nil, fmt.Errorf("duplicate param: %s", p.Name)
+		}
+		seen[p.Name] = struct{}{}
+
+		p.Type = normalizeParamType(p.Type)
+		if p.Type != paramTypeString && p.Type != paramTypeNumber && p.Type != paramTypeBool {
+			return nil, fmt.Errorf("param %s: unsupported type: %s", p.Name, p.Type)
+		}
+		choices := make([]string, 0, len(p.Choices))
+		for _, c := range p.Choices {
+			c, err := normalizeParamValue(p.Type, c)
+			if err != nil {
+				return nil, fmt.Errorf("param %s: invalid choice: %w", p.Name, err)
+			}
+			choices = append(choices, c)
+		}
+		p.Choices = nil
+		if len(choices) > 0 {
+			p.Choices = choices
+		}
+		if p.Default != "" {
+			value, err := checkParamValue(p, p.Default)
+			if err != nil {
+				return nil, fmt.Errorf("param %s: invalid default: %w", p.Name, err)
+			}
+			p.Default = value
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+func normalizeParamValue(typ string, value string) (string, error) {
+	switch typ {
+	case paramTypeNumber:
+		v := strings.TrimSpace(value)
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			return "", fmt.Errorf("not a number: %q", value)
+		}
+		return v, nil
+	case paramTypeBool:
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return "", fmt.Errorf("not a boolean: %q", value)
+		}
+		return strconv.FormatBool(b), nil
+	default:
+		return value, nil
+	}
+}
+
+func checkParamValue(p JobParam, value string) (string, error) {
+	value, err := normalizeParamValue(p.Type, value)
+	if err != nil {
+		return "", err
+	}
+	if len(p.Choices) == 0 {
+		return value, nil
+	}
+	for _, c := range p.Choices {
+		if c == value {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("%q is not one of %s", value, strings.Join(p.Choices, ", "))
+}
+
+// resolveJobParams merges the supplied values over the defaults and checks
+// them against the schema. Unknown names are rejected so typos do not go
+// unnoticed.
+func resolveJobParams(params []JobParam, values map[string]string) (map[string]string, error) {
+	byName := make(map[string]JobParam, len(params))
+	for _, p := range params {
+		byName[p.Name] = p
+	}
+	for name := range values {
+		if _, ok := byName[name]; !ok {
+			return nil, fmt.Errorf("unknown param: %s", name)
+		}
+	}
+
+	resolved := make(map[string]string, len(params))
+	var errs []error
+	for _, p := range params {
+		value, ok := values[p.Name]
+		if !ok {
+			value = p.Default
+		}
+		if value == "" {
+			if p.Required {
+				errs = append(errs, fmt.Errorf("param %s is required", p.Name))
+			}
+			resolved[p.Name] = ""
+			continue
+		}
+		checked, err := checkParamValue(p, value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("param %s: %w", p.Name, err))
+			continue
+		}
+		resolved[p.Name] = checked
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return resolved, nil
+}
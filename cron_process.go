@@ -0,0 +1,128 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	stopSignalKill      = "kill"
+	stopSignalInterrupt = "interrupt"
+	stopSignalCtrlBreak = "ctrl_break"
+	stopSignalWMClose   = "wm_close"
+)
+
+const (
+	stopStageSignal = "signal"
+	stopStageKill   = "kill"
+)
+
+const defaultStopGracePeriod = 10
+
+func normalizeStopSignal(value string) string {
+	v := strings.ToLower(strings.TrimSpace(value))
+	if v == stopSignalInterrupt || v == stopSignalCtrlBreak || v == stopSignalWMClose {
+		return v
+	}
+	return ""
+}
+
+func stopGracePeriod(job Job) time.Duration {
+	seconds := job.StopGracePeriod
+	if seconds <= 0 {
+		seconds = defaultStopGracePeriod
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// processStopper ends a started process at most once: first with the job's
+// stop signal, then with a hard kill when the grace period runs out.
+type processStopper struct {
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	tree   *processTree
+	done   <-chan struct{}
+	signal string
+	grace  time.Duration
+	reason string
+	stage  string
+}
+
+func newProcessStopper(cmd *exec.Cmd, tree *processTree, job Job, done <-chan struct{}) *processStopper {
+	return &processStopper{
+		cmd:    cmd,
+		tree:   tree,
+		done:   done,
+		signal: normalizeStopSignal(job.StopSignal),
+		grace:  stopGracePeriod(job),
+	}
+}
+
+// stop starts terminating the process in the background and records reason.
+// It returns false when a stop is already in progress.
+func (p *processStopper) stop(reason string) bool {
+	p.mu.Lock()
+	if p.reason != "" {
+		p.mu.Unlock()
+		return false
+	}
+	p.reason = reason
+	p.mu.Unlock()
+	go p.run()
+	return true
+}
+
+func (p *processStopper) run() {
+	if p.exited() {
+		return
+	}
+	if p.signal != "" {
+		p.setStage(stopStageSignal)
+		if err := sendStopSignal(p.cmd, p.tree, p.signal); err == nil {
+			select {
+			case <-p.done:
+				return
+			case <-time.After(p.grace):
+			}
+		}
+	}
+	if p.exited() {
+		return
+	}
+	p.setStage(stopStageKill)
+	p.kill()
+}
+
+// kill ends the whole process tree, falling back to the direct child when
+// the tree could not be tracked.
+func (p *processStopper) kill() {
+	if p.tree != nil && p.tree.kill() == nil {
+		return
+	}
+	_ = p.cmd.Process.Kill()
+}
+
+func (p *processStopper) exited() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *processStopper) setStage(stage string) {
+	p.mu.Lock()
+	p.stage = stage
+	p.mu.Unlock()
+}
+
+// result returns why the process was stopped and which stage ended it. Both
+// are empty when the process exited on its own.
+func (p *processStopper) result() (string, string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.reason, p.stage
+}
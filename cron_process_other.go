@@ -0,0 +1,74 @@
+//go:build !windows
+
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// processTree is the process group of a run, so a kill reaches every process
+// the job spawned.
+type processTree struct {
+	pgid int
+}
+
+func prepareProcessTree(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+func attachProcessTree(cmd *exec.Cmd) (*processTree, error) {
+	if cmd == nil || cmd.Process == nil {
+		return nil, errors.New("process is not running")
+	}
+	return &processTree{pgid: cmd.Process.Pid}, nil
+}
+
+func (t *processTree) kill() error {
+	return syscall.Kill(-t.pgid, syscall.SIGKILL)
+}
+
+func (t *processTree) close() {}
+
+func prepareStopSignal(cmd *exec.Cmd, job Job) {}
+
+// sendStopSignal maps every polite stop to SIGINT for the whole group;
+// Ctrl-Break and WM_CLOSE have no POSIX counterpart.
+func sendStopSignal(cmd *exec.Cmd, tree *processTree, signal string) error {
+	if cmd == nil || cmd.Process == nil {
+		return errors.New("process is not running")
+	}
+	if tree != nil {
+		return syscall.Kill(-tree.pgid, syscall.SIGINT)
+	}
+	return cmd.Process.Signal(syscall.SIGINT)
+}
+
+// processCPUTime reads rusage, which covers the direct child and the
+// descendants it waited for.
+func processCPUTime(state *os.ProcessState, tree *processTree) (user, system time.Duration) {
+	if state == nil {
+		return 0, 0
+	}
+	return state.UserTime(), state.SystemTime()
+}
+
+// processPeakMemory reads the max RSS from rusage, which covers the direct
+// child only.
+func processPeakMemory(state *os.ProcessState, tree *processTree) int64 {
+	if state == nil {
+		return 0
+	}
+	usage, ok := state.SysUsage().(*syscall.Rusage)
+	if !ok || usage == nil {
+		return 0
+	}
+	// Linux reports ru_maxrss in kilobytes.
+	return int64(usage.Maxrss) * 1024
+}
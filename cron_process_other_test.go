@@ -0,0 +1,40 @@
+//go:build !windows
+
+package main
+
+import (
+	"bytes"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestProcessTreeKillReachesGrandchildren(t *testing.T) {
+	// The background sleep inherits stdout, so Wait only returns once the
+	// grandchild is gone too.
+	cmd := exec.Command("sh", "-c", "sleep 60 & wait")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	prepareProcessTree(cmd)
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	tree, err := attachProcessTree(cmd)
+	if err != nil {
+		t.Fatalf("attach: %v", err)
+	}
+	defer tree.close()
+
+	time.Sleep(100 * time.Millisecond)
+	if err := tree.kill(); err != nil {
+		t.Fatalf("kill: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("process tree still running after kill")
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const wmClose = 0x0010
+
+var (
+	procAttachConsole = kernel32.NewProc("AttachConsole")
+	procFreeConsole   = kernel32.NewProc("FreeConsole")
+	procPostMessageW  = user32.NewProc("PostMessageW")
+)
+
+// consoleAttachMu serializes AttachConsole/FreeConsole, since a process can
+// be attached to only one console at a time.
+var consoleAttachMu sync.Mutex
+
+// prepareStopSignal puts the process in its own process group so a
+// Ctrl-Break can be delivered to it without reaching anything else.
+func prepareStopSignal(cmd *exec.Cmd, job Job) {
+	switch normalizeStopSignal(job.StopSignal) {
+	case stopSignalInterrupt, stopSignalCtrlBreak:
+		if cmd.SysProcAttr == nil {
+			cmd.SysProcAttr = &syscall.SysProcAttr{}
+		}
+		cmd.SysProcAttr.CreationFlags |= windows.CREATE_NEW_PROCESS_GROUP
+	}
+}
+
+// processTree is a Job Object holding the run's process and everything it
+// spawns, so a kill reaches grandchildren such as the program started by
+// "cmd.exe /c".
+type processTree struct {
+	job windows.Handle
+}
+
+func prepareProcessTree(cmd *exec.Cmd) {}
+
+// attachProcessTree assigns the started process to a new Job Object. Children
+// spawned before the assignment completes are not tracked.
+func attachProcessTree(cmd *exec.Cmd) (*processTree, error) {
+	if cmd == nil || cmd.Process == nil {
+		return nil, errors.New("process is not running")
+	}
+	job, err := windows.CreateJobObject(nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	proc, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE, false, uint32(cmd.Process.Pid))
+	if err != nil {
+		_ = windows.CloseHandle(job)
+		return nil, err
+	}
+	defer windows.CloseHandle(proc)
+	if err := windows.AssignProcessToJobObject(job, proc); err != nil {
+		_ = windows.CloseHandle(job)
+		return nil, err
+	}
+	return &processTree{job: job}, nil
+}
+
+func (t *processTree) kill() error {
+	return windows.TerminateJobObject(t.job, 1)
+}
+
+func (t *processTree) close() {
+	_ = windows.CloseHandle(t.job)
+}
+
+// peakMemory reports the peak committed memory of the whole tree.
+func (t *processTree) peakMemory() (int64, error) {
+	var info windows.JOBOBJECT_EXTENDED_LIMIT_INFORMATION
+	if err := windows.QueryInformationJobObject(t.job, windows.JobObjectExtendedLimitInformation, uintptr(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info)), nil); err != nil {
+		return 0, err
+	}
+	return int64(info.PeakJobMemoryUsed), nil
+}
+
+// processPeakMemory prefers the Job Object, since rusage on Windows carries no
+// memory counters.
+func processPeakMemory(state *os.ProcessState, tree *processTree) int64 {
+	if tree == nil {
+		return 0
+	}
+	peak, err := tree.peakMemory()
+	if err != nil {
+		return 0
+	}
+	return peak
+}
+
+type jobObjectBasicAccountingInformation struct {
+	TotalUserTime             int64
+	TotalKernelTime           int64
+	ThisPeriodTotalUserTime   int64
+	ThisPeriodTotalKernelTime int64
+	TotalPageFaultCount       uint32
+	TotalProcesses            uint32
+	ActiveProcesses           uint32
+	TotalTerminatedProcesses  uint32
+}
+
+// cpuTime reports the CPU time of the whole tree. The Job Object counts it in
+// 100ns units.
+func (t *processTree) cpuTime() (user, system time.Duration, err error) {
+	var info jobObjectBasicAccountingInformation
+	if err := windows.QueryInformationJobObject(t.job, windows.JobObjectBasicAccountingInformation, uintptr(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info)), nil); err != nil {
+		return 0, 0, err
+	}
+	return time.Duration(info.TotalUserTime) * 100, time.Duration(info.TotalKernelTime) * 100, nil
+}
+
+// processCPUTime prefers the Job Object, so the CPU time covers the same
+// processes as processPeakMemory, and falls back to the direct child.
+func processCPUTime(state *os.ProcessState, tree *processTree) (user, system time.Duration) {
+	if tree != nil {
+		if user, system, err := tree.cpuTime(); err == nil {
+			return user, system
+		}
+	}
+	if state == nil {
+		return 0, 0
+	}
+	return state.UserTime(), state.SystemTime()
+}
+
+type jobObjectProcessIDList struct {
+	NumberOfAssignedProcesses uint32
+	NumberOfProcessIdsInList  uint32
+	ProcessIdList             [64]uintptr
+}
+
+func (t *processTree) pids() ([]uint32, error) {
+	var list jobObjectProcessIDList
+	if err := windows.QueryInformationJobObject(t.job, windows.JobObjectBasicProcessIdList, uintptr(unsafe.Pointer(&list)), uint32(unsafe.Sizeof(list)), nil); err != nil && err != windows.ERROR_MORE_DATA {
+		return nil, err
+	}
+	pids := make([]uint32, 0, list.NumberOfProcessIdsInList)
+	for i := uint32(0); i < list.NumberOfProcessIdsInList && int(i) < len(list.ProcessIdList); i++ {
+		pids = append(pids, uint32(list.ProcessIdList[i]))
+	}
+	return pids, nil
+}
+
+// sendStopSignal delivers the polite stop. Ctrl-Break reaches the whole
+// process group created by prepareStopSignal; WM_CLOSE is posted to the
+// windows of every process in the tree.
+func sendStopSignal(cmd *exec.Cmd, tree *processTree, signal string) error {
+	if cmd == nil || cmd.Process == nil {
+		return errors.New("process is not running")
+	}
+	pid := uint32(cmd.Process.Pid)
+	switch signal {
+	case stopSignalInterrupt, stopSignalCtrlBreak:
+		return sendCtrlBreak(pid)
+	case stopSignalWMClose:
+		pids := []uint32{pid}
+		if tree != nil {
+			if treePIDs, err := tree.pids(); err == nil && len(treePIDs) > 0 {
+				pids = treePIDs
+			}
+		}
+		return postCloseToWindows(pids)
+	default:
+		return errors.New("unsupported stop signal")
+	}
+}
+
+// sendCtrlBreak borrows the child's console, since the GUI process has none
+// of its own and console events only reach processes sharing a console.
+func sendCtrlBreak(pid uint32) error {
+	consoleAttachMu.Lock()
+	defer consoleAttachMu.Unlock()
+
+	if r, _, err := procAttachConsole.Call(uintptr(pid)); r == 0 {
+		return err
+	}
+	defer procFreeConsole.Call()
+	return windows.GenerateConsoleCtrlEvent(windows.CTRL_BREAK_EVENT, pid)
+}
+
+// The EnumWindows callback is created once because callbacks made with
+// windows.NewCallback are never released.
+var (
+	closeWindowsMu    sync.Mutex
+	closeWindowsPIDs  map[uint32]struct{}
+	closeWindowsFound []windows.HWND
+	closeWindowsEnum  = windows.NewCallback(func(hwnd windows.HWND, _ uintptr) uintptr {
+		var owner uint32
+		if _, err := windows.GetWindowThreadProcessId(hwnd, &owner); err == nil {
+			if _, ok := closeWindowsPIDs[owner]; ok {
+				closeWindowsFound = append(closeWindowsFound, hwnd)
+			}
+		}
+		return 1
+	})
+)
+
+func postCloseToWindows(pids []uint32) error {
+	closeWindowsMu.Lock()
+	closeWindowsPIDs = make(map[uint32]struct{}, len(pids))
+	for _, pid := range pids {
+		closeWindowsPIDs[pid] = struct{}{}
+	}
+	closeWindowsFound = nil
+	err := windows.EnumWindows(closeWindowsEnum, unsafe.Pointer(nil))
+	hwnds := closeWindowsFound
+	closeWindowsFound = nil
+	closeWindowsPIDs = nil
+	closeWindowsMu.Unlock()
+	if err != nil {
+		return err
+	}
+	if len(hwnds) == 0 {
+		return errors.New("process has no top-level windows")
+	}
+	for _, hwnd := range hwnds {
+		_, _, _ = procPostMessageW.Call(uintptr(hwnd), wmClose, 0, 0)
+	}
+	return nil
+}
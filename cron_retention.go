@@ -0,0 +1,340 @@
+package main
+
+import "time"
+
+// LogRetention limits how much run history logs.sqlite keeps. Zero means no
+// limit. The Failed* limits, when set, replace the regular ones for runs that
+// did not succeed, so failures can be kept longer. MaxDatabaseMB only applies
+// globally.
+type LogRetention struct {
+	MaxEntriesPerJob       int `json:"maxEntriesPerJob,omitempty" yaml:"maxEntriesPerJob,omitempty"`
+	MaxAgeDays             int `json:"maxAgeDays,omitempty" yaml:"maxAgeDays,omitempty"`
+	MaxDatabaseMB          int `json:"maxDatabaseMB,omitempty" yaml:"maxDatabaseMB,omitempty"`
+	FailedMaxEntriesPerJob int `json:"failedMaxEntriesPerJob,omitempty" yaml:"failedMaxEntriesPerJob,omitempty"`
+	FailedMaxAgeDays       int `json:"failedMaxAgeDays,omitempty" yaml:"failedMaxAgeDays,omitempty"`
+}
+
+const (
+	logPruneInterval  = time.Hour
+	logVacuumInterval = 24 * time.Hour
+)
+
+func normalizeLogRetention(r LogRetention) LogRetention {
+	for _, v := range []*int{&r.MaxEntriesPerJob, &r.MaxAgeDays, &r.MaxDatabaseMB, &r.FailedMaxEntriesPerJob, &r.FailedMaxAgeDays} {
+		if *v < 0 {
+			*v = 0
+		}
+	}
+	return r
+}
+
+// normalizeJobLogRetention drops the database size limit, which cannot be
+// set per job, and returns nil when nothing is overridden.
+func normalizeJobLogRetention(r *LogRetention) *LogRetention {
+	if r == nil {
+		return nil
+	}
+	v := normalizeLogRetention(*r)
+	v.MaxDatabaseMB = 0
+	if v == (LogRetention{}) {
+		return nil
+	}
+	return &v
+}
+
+// withOverride returns r with the non-zero fields of a job's override applied.
+func (r LogRetention) withOverride(o *LogRetention) LogRetention {
+	if o == nil {
+		return r
+	}
+	if o.MaxEntriesPerJob > 0 {
+		r.MaxEntriesPerJob = o.MaxEntriesPerJob
+	}
+	if o.MaxAgeDays > 0 {
+		r.MaxAgeDays = o.MaxAgeDays
+	}
+	if o.FailedMaxEntriesPerJob > 0 {
+		r.FailedMaxEntriesPerJob = o.FailedMaxEntriesPerJob
+	}
+	if o.FailedMaxAgeDays > 0 {
+		r.FailedMaxAgeDays = o.FailedMaxAgeDays
+	}
+	return r
+}
+
+func (r LogRetention) limitsEntries() bool {
+	return r.MaxEntriesPerJob > 0 || r.MaxAgeDays > 0 || r.FailedMaxEntriesPerJob > 0 || r.FailedMaxAgeDays > 0
+}
+
+func (r LogRetention) keepsFailedLonger() bool {
+	return r.FailedMaxEntriesPerJob > 0 || r.FailedMaxAgeDays > 0
+}
+
+// pruneJob deletes the runs of jobID that fall outside r, together with their
+// steps and matrix children. Running rows and matrix children are never
+// selected, so a run that is still in progress is left alone.
+func (s *logStore) pruneJob(jobID string, r LogRetention, now time.Time) (int, error) {
+	if err := s.ensureInit(); err != nil {
+		return 0, err
+	}
+
+	// Skipped triggers are not failures and do not get the longer limits.
+	const (
+		allRuns    = ""
+		successful = " AND status IN ('success', 'skipped')"
+		failed     = " AND status NOT IN ('success', 'skipped')"
+	)
+	var ids []string
+	collect := func(query string, args ...any) error {
+		found, err := s.queryIDs(query, args...)
+		ids = append(ids, found...)
+		return err
+	}
+	byAge := func(cond string, days int) error {
+		if days <= 0 {
+			return nil
+		}
+		cutoff := now.AddDate(0, 0, -days).UnixMilli()
+		return collect(`SELECT id FROM job_logs WHERE job_id = ? AND parent_id = '' AND status <> 'running'`+cond+` AND started_at < ?;`, jobID, cutoff)
+	}
+	byCount := func(cond string, keep int) error {
+		if keep <= 0 {
+			return nil
+		}
+		return collect(`SELECT id FROM job_logs WHERE job_id = ? AND parent_id = '' AND status <> 'running'`+cond+`
+			ORDER BY started_at DESC LIMIT -1 OFFSET ?;`, jobID, keep)
+	}
+
+	failedAge, failedCount := r.MaxAgeDays, r.MaxEntriesPerJob
+	if r.FailedMaxAgeDays > 0 {
+		failedAge = r.FailedMaxAgeDays
+	}
+	if r.FailedMaxEntriesPerJob > 0 {
+		failedCount = r.FailedMaxEntriesPerJob
+	}
+	var err error
+	if failedAge == r.MaxAgeDays {
+		err = byAge(allRuns, r.MaxAgeDays)
+	} else if err = byAge(successful, r.MaxAgeDays); err == nil {
+		err = byAge(failed, failedAge)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if r.FailedMaxEntriesPerJob == 0 {
+		err = byCount(allRuns, r.MaxEntriesPerJob)
+	} else if err = byCount(successful, r.MaxEntriesPerJob); err == nil {
+		err = byCount(failed, failedCount)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return s.deleteEntries(ids)
+}
+
+// pruneToSize deletes the oldest runs until the live data fits in maxBytes.
+// Successful runs go first when r keeps failures longer. Freed pages are only
+// returned to the file system by the VACUUM that follows.
+func (s *logStore) pruneToSize(maxBytes int64, r LogRetention) (int, error) {
+	if err := s.ensureInit(); err != nil {
+		return 0, err
+	}
+	order := `started_at ASC`
+	if r.keepsFailedLonger() {
+		order = `CASE WHEN status IN ('success', 'skipped') THEN 0 ELSE 1 END, started_at ASC`
+	}
+
+	total := 0
+	for i := 0; i < 10; i++ {
+		size, err := s.dataSize()
+		if err != nil || size <= maxBytes {
+			return total, err
+		}
+		var count int64
+		if err := s.db.QueryRow(`SELECT COUNT(*) FROM job_logs WHERE parent_id = '';`).Scan(&count); err != nil || count == 0 {
+			return total, err
+		}
+		// Aim a little past the limit so a few more appends do not trigger
+		// another round right away.
+		n := (size-maxBytes)*count/size + count/20 + 1
+		ids, err := s.queryIDs(`SELECT id FROM job_logs WHERE parent_id = '' AND status <> 'running' ORDER BY `+order+` LIMIT ?;`, n)
+		if err != nil {
+			return total, err
+		}
+		deleted, err := s.deleteEntries(ids)
+		total += deleted
+		if err != nil || deleted == 0 {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// dataSize is the size of the pages in use, which unlike the file size drops
+// as soon as rows are deleted.
+func (s *logStore) dataSize() (int64, error) {
+	var pageCount, freePages, pageSize int64
+	if err := s.db.QueryRow(`PRAGMA page_count;`).Scan(&pageCount); err != nil {
+		return 0, err
+	}
+	if err := s.db.QueryRow(`PRAGMA freelist_count;`).Scan(&freePages); err != nil {
+		return 0, err
+	}
+	if err := s.db.QueryRow(`PRAGMA page_size;`).Scan(&pageSize); err != nil {
+		return 0, err
+	}
+	return (pageCount - freePages) * pageSize, nil
+}
+
+func (s *logStore) queryIDs(query string, args ...any) ([]string, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// storedJobIDs lists every job with stored runs, including deleted jobs.
+func (s *logStore) storedJobIDs() ([]string, error) {
+	if err := s.ensureInit(); err != nil {
+		return nil, err
+	}
+	return s.queryIDs(`SELECT DISTINCT job_id FROM job_logs WHERE parent_id = '';`)
+}
+
+// deleteEntries deletes top-level runs with their steps and matrix children
+// in one transaction.
+func (s *logStore) deleteEntries(ids []string) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	deleted := 0
+	for _, id := range ids {
+		if _, err := tx.Exec(`DELETE FROM job_log_steps WHERE entry_id = ?;`, id); err != nil {
+			return 0, err
+		}
+		res, err := tx.Stmt(s.deleteEntryStmt).Exec(id, id)
+		if err != nil {
+			return 0, err
+		}
+		if n, err := res.RowsAffected(); err == nil && n > 0 {
+			deleted++
+		}
+	}
+	return deleted, tx.Commit()
+}
+
+func (s *logStore) checkpoint() error {
+	if err := s.ensureInit(); err != nil {
+		return err
+	}
+	_, err := s.db.Exec(`PRAGMA wal_checkpoint(TRUNCATE);`)
+	return err
+}
+
+func (s *logStore) vacuum() error {
+	if err := s.ensureInit(); err != nil {
+		return err
+	}
+	if _, err := s.db.Exec(`VACUUM;`); err != nil {
+		return err
+	}
+	return s.checkpoint()
+}
+
+func (s *CronService) setLogRetention(f func() LogRetention) {
+	s.mu.Lock()
+	s.logRetention = f
+	s.mu.Unlock()
+	s.requestLogPrune("")
+}
+
+// requestLogPrune asks the maintenance loop to apply retention to jobID, or
+// to every job when jobID is empty. Requests are dropped while the queue is
+// full; the hourly sweep catches up.
+func (s *CronService) requestLogPrune(jobID string) {
+	select {
+	case s.pruneQueue <- jobID:
+	default:
+	}
+}
+
+func (s *CronService) runLogMaintenance() {
+	ticker := time.NewTicker(logPruneInterval)
+	defer ticker.Stop()
+	lastVacuum := time.Now()
+	for {
+		select {
+		case jobID := <-s.pruneQueue:
+			s.pruneLogs(jobID)
+		case now := <-ticker.C:
+			s.pruneLogs("")
+			s.logsMu.Lock()
+			if now.Sub(lastVacuum) >= logVacuumInterval {
+				lastVacuum = now
+				_ = s.logs.vacuum()
+			} else {
+				_ = s.logs.checkpoint()
+			}
+			s.logsMu.Unlock()
+		}
+	}
+}
+
+func (s *CronService) pruneLogs(jobID string) {
+	s.mu.Lock()
+	retentionFn := s.logRetention
+	s.mu.Unlock()
+	if retentionFn == nil {
+		return
+	}
+	global := normalizeLogRetention(retentionFn())
+
+	jobIDs := []string{jobID}
+	if jobID == "" {
+		s.logsMu.Lock()
+		ids, err := s.logs.storedJobIDs()
+		s.logsMu.Unlock()
+		if err != nil {
+			return
+		}
+		jobIDs = ids
+	}
+
+	now := time.Now()
+	for _, id := range jobIDs {
+		s.mu.Lock()
+		r := global.withOverride(s.jobs[id].LogRetention)
+		s.mu.Unlock()
+		if !r.limitsEntries() {
+			continue
+		}
+		s.logsMu.Lock()
+		_, _ = s.logs.pruneJob(id, r, now)
+		s.logsMu.Unlock()
+	}
+
+	if global.MaxDatabaseMB > 0 {
+		s.logsMu.Lock()
+		if n, err := s.logs.pruneToSize(int64(global.MaxDatabaseMB)<<20, global); err == nil && n > 0 {
+			_ = s.logs.vacuum()
+		}
+		s.logsMu.Unlock()
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+type runOptions struct {
+	triggerSource string
+	scheduledAt   time.Time
+	overrides     *RunOverrides
+}
+
+// RunOverrides changes a single run of a job without touching its stored
+// definition. It is recorded on the log entry so the run can be told apart.
+type RunOverrides struct {
+	Args    []string          `json:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+	WorkDir string            `json:"workDir,omitempty"`
+	Params  map[string]string `json:"params,omitempty"`
+}
+
+// newRunOverrides returns nil when nothing is overridden.
+func newRunOverrides(extraArgs []string, env map[string]string, workDir string, params map[string]string) (*RunOverrides, error) {
+	o := &RunOverrides{
+		Args:    extraArgs,
+		WorkDir: strings.TrimSpace(workDir),
+	}
+	for name, value := range params {
+		if o.Params == nil {
+			o.Params = make(map[string]string, len(params))
+		}
+		o.Params[strings.TrimSpace(name)] = value
+	}
+	for key, value := range env {
+		key = strings.TrimSpace(key)
+		if key == "" || strings.Contains(key, "=") {
+			return nil, fmt.Errorf("invalid env name: %q", key)
+		}
+		if strings.HasPrefix(strings.ToUpper(key), "WINCRON_") {
+			return nil, errors.New("WINCRON_* variables cannot be overridden")
+		}
+		if o.Env == nil {
+			o.Env = make(map[string]string, len(env))
+		}
+		o.Env[key] = value
+	}
+	if len(o.Args) == 0 && len(o.Env) == 0 && o.WorkDir == "" && len(o.Params) == 0 {
+		return nil, nil
+	}
+	return o, nil
+}
+
+func (o *RunOverrides) apply(job Job) Job {
+	if o == nil {
+		return job
+	}
+	if len(o.Args) > 0 {
+		job.Args = append(append([]string{}, job.Args...), o.Args...)
+	}
+	if o.WorkDir != "" {
+		job.WorkDir = o.WorkDir
+	}
+	return job
+}
+
+func (o *RunOverrides) params() map[string]string {
+	if o == nil {
+		return nil
+	}
+	return o.Params
+}
+
+// env returns the override variables with their values rendered, so they can
+// refer to {{.Params.x}} like Args.
+func (o *RunOverrides) env(c runContext) ([]string, error) {
+	if o == nil || len(o.Env) == 0 {
+		return nil, nil
+	}
+	out := make([]string, 0, len(o.Env))
+	for key, value := range o.Env {
+		rendered, err := c.render(value)
+		if err != nil {
+			return nil, fmt.Errorf("env %s: %w", key, err)
+		}
+		out = append(out, key+"="+rendered)
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// runContext describes a single run. It is exported to the child process as
+// WINCRON_* environment variables and to Args/WorkDir as template data.
+type runContext struct {
+	JobID       string
+	JobName     string
+	RunID       string
+	Trigger     string
+	ScheduledAt string
+	Attempt     int
+	DataDir     string
+	Params      map[string]string
+	Item        string
+}
+
+func newRunContext(job Job, entry JobLogEntry, run runOptions, start time.Time) runContext {
+	scheduledAt := run.scheduledAt
+	if scheduledAt.IsZero() {
+		scheduledAt = start
+	}
+	dataDir, err := resolveDataDir()
+	if err != nil {
+		dataDir = defaultDataDir()
+	}
+	return runContext{
+		JobID:       job.ID,
+		JobName:     job.Name,
+		RunID:       entry.ID,
+		Trigger:     entry.TriggerSource,
+		ScheduledAt: scheduledAt.Format(time.RFC3339),
+		// Retries are not supported yet, so every run is its first attempt.
+		Attempt: 1,
+		DataDir: dataDir,
+	}
+}
+
+func (c runContext) env() []string {
+	env := []string{
+		"WINCRON_JOB_ID=" + c.JobID,
+		"WINCRON_JOB_NAME=" + c.JobName,
+		"WINCRON_RUN_ID=" + c.RunID,
+		"WINCRON_TRIGGER=" + c.Trigger,
+		"WINCRON_SCHEDULED_AT=" + c.ScheduledAt,
+		"WINCRON_ATTEMPT=" + strconv.Itoa(c.Attempt),
+		"WINCRON_DATA_DIR=" + c.DataDir,
+	}
+	params := make([]string, 0, len(c.Params))
+	for name, value := range c.Params {
+		params = append(params, "WINCRON_PARAM_"+strings.ToUpper(name)+"="+value)
+	}
+	sort.Strings(params)
+	env = append(env, params...)
+	if c.Item != "" {
+		env = append(env, "WINCRON_ITEM="+c.Item)
+	}
+	return env
+}
+
+// render expands {{.Field}} placeholders. Text without "{{" is returned as is,
+// so plain arguments never fail to parse.
+func (c runContext) render(text string) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	tmpl, err := template.New("").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, c); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func (c runContext) renderAll(values []string) ([]string, error) {
+	if values == nil {
+		return nil, nil
+	}
+	out := make([]string, len(values))
+	for i, v := range values {
+		rendered, err := c.render(v)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = rendered
+	}
+	return out, nil
+}
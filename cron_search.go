@@ -0,0 +1,341 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LogSearchQuery selects runs by text and structured filters. Text is matched
+// against command_line, stdout, stderr and error: words must all appear, and
+// "quoted text" is matched as a phrase. From and To accept RFC 3339 times or
+// plain dates, To being inclusive.
+type LogSearchQuery struct {
+	Text          string `json:"text"`
+	JobID         string `json:"jobId"`
+	Status        string `json:"status"`
+	ExitCode      *int   `json:"exitCode"`
+	TriggerSource string `json:"triggerSource"`
+	From          string `json:"from"`
+	To            string `json:"to"`
+	Offset        int    `json:"offset"`
+	Limit         int    `json:"limit"`
+}
+
+// LogSearchHit is a matching run. Highlights are the matched ranges of
+// Snippet, in the same rune offsets as OutputSpan.
+type LogSearchHit struct {
+	Entry      JobLogEntry  `json:"entry"`
+	Snippet    string       `json:"snippet,omitempty"`
+	Highlights []OutputSpan `json:"highlights,omitempty"`
+}
+
+type LogSearchResult struct {
+	Items      []LogSearchHit `json:"items"`
+	TotalCount int            `json:"totalCount"`
+	HasMore    bool           `json:"hasMore"`
+}
+
+// Markers wrapped around matches by snippet(), turned into Highlights.
+const (
+	searchMatchOpen  = "\x02"
+	searchMatchClose = "\x03"
+)
+
+// initLogSearch creates the search index and fills it from existing rows.
+// The index keeps no copy of the text and SQLite cannot read compressed
+// output, so wincron feeds it with indexLogRows; only dropping the entries of
+// deleted rows is left to a trigger. Index entries are keyed on search_id
+// rather than the rowid, which VACUUM may renumber; new rows get the next
+// search_id from a trigger, whoever inserts them.
+func initLogSearch(db sqlConn) error {
+	if err := addSQLiteColumnIfMissing(db, "job_logs", "search_id", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := execAll(db,
+		`UPDATE job_logs SET search_id = rowid WHERE search_id = 0;`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_job_logs_search_id ON job_logs(search_id);`,
+		`CREATE TRIGGER IF NOT EXISTS job_logs_search_id AFTER INSERT ON job_logs WHEN new.search_id = 0 BEGIN
+			UPDATE job_logs SET search_id = (SELECT MAX(search_id) FROM job_logs) + 1 WHERE rowid = new.rowid;
+		END;`,
+		`CREATE VIRTUAL TABLE IF NOT EXISTS job_logs_fts USING fts5(
+			command_line, stdout, stderr, error,
+			content = '', contentless_delete = 1
+		);`,
+		`CREATE TRIGGER IF NOT EXISTS job_logs_fts_delete AFTER DELETE ON job_logs BEGIN
+			DELETE FROM job_logs_fts WHERE rowid = old.search_id;
+		END;`,
+	); err != nil {
+		return err
+	}
+	return rebuildLogSearch(db)
+}
+
+// rebuildLogSearch indexes every row again.
+func rebuildLogSearch(db sqlConn) error {
+	if _, err := db.Exec(`INSERT INTO job_logs_fts(job_logs_fts) VALUES ('delete-all');`); err != nil {
+		return err
+	}
+	return indexLogRows(db, "1")
+}
+
+// indexLogRows (re)indexes the rows of job_logs matching cond with their
+// decompressed output. It reads in batches, as a rebuild covers every row.
+func indexLogRows(db sqlConn, cond string, args ...any) error {
+	type indexedRow struct {
+		searchID                          int64
+		commandLine, stdout, stderr, text string
+	}
+	var afterID int64
+	for {
+		rows, err := db.Query(`SELECT search_id, command_line, stdout, stderr, output_compression, error
+			FROM job_logs
+			WHERE (`+cond+`) AND search_id > ?
+			ORDER BY search_id
+			LIMIT ?;`, append(append([]any{}, args...), afterID, logCompressBatch)...)
+		if err != nil {
+			return err
+		}
+		var batch []indexedRow
+		for rows.Next() {
+			var (
+				r              indexedRow
+				stdout, stderr []byte
+				compression    string
+			)
+			if err := rows.Scan(&r.searchID, &r.commandLine, &stdout, &stderr, &compression, &r.text); err != nil {
+				_ = rows.Close()
+				return err
+			}
+			r.stdout = decodeLogOutput(stdout, compression)
+			r.stderr = decodeLogOutput(stderr, compression)
+			batch = append(batch, r)
+		}
+		_ = rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		for _, r := range batch {
+			if _, err := db.Exec(`INSERT OR REPLACE INTO job_logs_fts(rowid, command_line, stdout, stderr, error)
+				VALUES (?, ?, ?, ?, ?);`, r.searchID, r.commandLine, r.stdout, r.stderr, r.text); err != nil {
+				return err
+			}
+		}
+		if len(batch) < logCompressBatch {
+			return nil
+		}
+		afterID = batch[len(batch)-1].searchID
+	}
+}
+
+// ftsMatchQuery turns user input into an FTS5 query that cannot fail to
+// parse: every word or quoted phrase becomes a quoted string.
+func ftsMatchQuery(text string) string {
+	var terms []string
+	for {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			break
+		}
+		var term string
+		if strings.HasPrefix(text, `"`) {
+			end := strings.Index(text[1:], `"`)
+			if end < 0 {
+				term, text = text[1:], ""
+			} else {
+				term, text = text[1:end+1], text[end+2:]
+			}
+		} else if i := strings.IndexAny(text, " \t\r\n"); i >= 0 {
+			term, text = text[:i], text[i:]
+		} else {
+			term, text = text, ""
+		}
+		if term = strings.TrimSpace(term); term != "" {
+			terms = append(terms, `"`+strings.ReplaceAll(term, `"`, `""`)+`"`)
+		}
+	}
+	return strings.Join(terms, " ")
+}
+
+// parseLogSearchTime returns Unix milliseconds for an RFC 3339 time or a date
+// in local time. For an end bound, a date means the end of that day.
+func parseLogSearchTime(value string, end bool) (int64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UnixMilli(), nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time: %q", value)
+	}
+	if end {
+		t = t.AddDate(0, 0, 1).Add(-time.Millisecond)
+	}
+	return t.UnixMilli(), nil
+}
+
+func newLogSearchFilter(q LogSearchQuery) (logFilter, error) {
+	f := logFilter{
+		jobID:    q.JobID,
+		status:   q.Status,
+		exitCode: q.ExitCode,
+		match:    ftsMatchQuery(q.Text),
+	}
+	if v := strings.TrimSpace(q.Status); v != "" && normalizeLogStatusFilter(v) == "" {
+		return logFilter{}, fmt.Errorf("unknown status: %s", q.Status)
+	}
+	if v := strings.TrimSpace(q.TriggerSource); v != "" {
+		if f.triggerSource = normalizeLogTriggerSource(v); f.triggerSource == "" {
+			return logFilter{}, fmt.Errorf("unknown trigger source: %s", q.TriggerSource)
+		}
+	}
+	var err error
+	if f.fromMs, err = parseLogSearchTime(q.From, false); err != nil {
+		return logFilter{}, err
+	}
+	if f.toMs, err = parseLogSearchTime(q.To, true); err != nil {
+		return logFilter{}, err
+	}
+	return f, nil
+}
+
+// SearchLogs finds stored runs, newest first. Running entries are not
+// searched.
+func (s *CronService) SearchLogs(query LogSearchQuery) (LogSearchResult, error) {
+	filter, err := newLogSearchFilter(query)
+	if err != nil {
+		return LogSearchResult{}, err
+	}
+	s.logsMu.Lock()
+	defer s.logsMu.Unlock()
+	return s.logs.search(filter, query.Offset, query.Limit)
+}
+
+func (s *logStore) search(filter logFilter, offset int, limit int) (LogSearchResult, error) {
+	if err := s.ensureInit(); err != nil {
+		return LogSearchResult{}, err
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+
+	totalCount, err := s.count(filter)
+	if err != nil {
+		return LogSearchResult{}, err
+	}
+	result := LogSearchResult{Items: []LogSearchHit{}, TotalCount: totalCount}
+	if totalCount == 0 || offset >= totalCount {
+		return result, nil
+	}
+
+	where, args := filter.where()
+	rows, err := s.db.Query(`SELECT `+logEntryColumns+`
+		FROM job_logs`+where+`
+		ORDER BY started_at DESC, id DESC
+		LIMIT ? OFFSET ?;`, append(args, limit, offset)...)
+	if err != nil {
+		return LogSearchResult{}, err
+	}
+	entries, err := scanLogEntries(rows)
+	if err != nil {
+		return LogSearchResult{}, err
+	}
+	if err := s.loadSteps(entries); err != nil {
+		return LogSearchResult{}, err
+	}
+	if err := s.loadChildren(entries); err != nil {
+		return LogSearchResult{}, err
+	}
+
+	snippets := map[string]string{}
+	if filter.match != "" && len(entries) > 0 {
+		if snippets, err = s.searchSnippets(filter.match, entries); err != nil {
+			return LogSearchResult{}, err
+		}
+	}
+	for _, entry := range entries {
+		snippet, highlights := splitSearchSnippet(snippets[entry.ID])
+		result.Items = append(result.Items, LogSearchHit{Entry: entry, Snippet: snippet, Highlights: highlights})
+	}
+	result.HasMore = offset+len(entries) < totalCount
+	return result, nil
+}
+
+// searchSnippets runs the match again over the decompressed text of entries,
+// in a temporary index: the stored one keeps no text to cut snippets from.
+func (s *logStore) searchSnippets(match string, entries []JobLogEntry) (map[string]string, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	// Nothing of this is meant to last.
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	if _, err := tx.Exec(`CREATE VIRTUAL TABLE temp.job_logs_snippets USING fts5(command_line, stdout, stderr, error);`); err != nil {
+		return nil, err
+	}
+	for i, entry := range entries {
+		if _, err := tx.Exec(`INSERT INTO temp.job_logs_snippets(rowid, command_line, stdout, stderr, error) VALUES (?, ?, ?, ?, ?);`,
+			i, entry.CommandLine, entry.Stdout, entry.Stderr, entry.Error); err != nil {
+			return nil, err
+		}
+	}
+	rows, err := tx.Query(`SELECT rowid, snippet(job_logs_snippets, -1, char(2), char(3), '…', 16)
+		FROM temp.job_logs_snippets
+		WHERE job_logs_snippets MATCH ?;`, match)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snippets := make(map[string]string, len(entries))
+	for rows.Next() {
+		var (
+			i       int
+			snippet string
+		)
+		if err := rows.Scan(&i, &snippet); err != nil {
+			return nil, err
+		}
+		if i >= 0 && i < len(entries) {
+			snippets[entries[i].ID] = snippet
+		}
+	}
+	return snippets, rows.Err()
+}
+
+// splitSearchSnippet removes the match markers from a snippet and returns
+// where they were.
+func splitSearchSnippet(raw string) (string, []OutputSpan) {
+	if raw == "" {
+		return "", nil
+	}
+	var (
+		b          strings.Builder
+		highlights []OutputSpan
+		pos        int
+		start      = -1
+	)
+	for _, r := range raw {
+		switch string(r) {
+		case searchMatchOpen:
+			start = pos
+		case searchMatchClose:
+			if start >= 0 && pos > start {
+				highlights = append(highlights, OutputSpan{Start: start, End: pos})
+			}
+			start = -1
+		default:
+			b.WriteRune(r)
+			pos++
+		}
+	}
+	return b.String(), highlights
+}
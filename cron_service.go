@@ -49,11 +49,38 @@ type CronService struct {
 	onStarted     func(JobLogEntry)
 	onExecuted    func(JobLogEntry)
 	onJobsChanged func()
+	logRetention  func() LogRetention
+	pruneQueue    chan string
+	// skippedRunLogDisabled turns off recordSkippedRun.
+	skippedRunLogDisabled func() bool
 }
 
 type runningJobInstance struct {
-	cmd   *exec.Cmd
-	entry JobLogEntry
+	cmd     *exec.Cmd
+	entry   JobLogEntry
+	stopper *processStopper
+	done    <-chan struct{}
+	// children are the processes of a matrix run, stopped together with it.
+	children []*processStopper
+	// stopReason is kept for the whole run so that a multi-step job does not
+	// start its next step after being stopped.
+	stopReason string
+}
+
+func (inst *runningJobInstance) stopLocked(reason string) {
+	if inst.stopReason == "" {
+		inst.stopReason = reason
+	}
+	if inst.stopper != nil {
+		inst.stopper.stop(reason)
+	}
+	for _, child := range inst.children {
+		child.stop(reason)
+	}
+}
+
+func (inst *runningJobInstance) hasProcessLocked() bool {
+	return inst.stopper != nil || len(inst.children) > 0
 }
 
 func applyJobWindowsProcessOptions(cmd *exec.Cmd, job Job) {
@@ -148,11 +175,23 @@ func newRunningLogEntry(job Job, triggerSource string, startedAt time.Time) JobL
 		JobID:         job.ID,
 		JobName:       job.Name,
 		TriggerSource: normalizeLogTriggerSource(triggerSource),
-		CommandLine:   renderCommandLine(job.Command, job.Args),
+		CommandLine:   renderJobCommandLine(job, job.Args),
 		StartedAt:     startedAt.Format(time.RFC3339),
 	}
 }
 
+// setLogEntrySchedule records when a cron run was planned to start and how
+// late start is; sleep, a busy machine or waiting for an old run all add up.
+func setLogEntrySchedule(entry *JobLogEntry, scheduledAt time.Time, start time.Time) {
+	if scheduledAt.IsZero() {
+		return
+	}
+	entry.ScheduledAt = scheduledAt.Format(time.RFC3339)
+	if late := start.Sub(scheduledAt); late > 0 {
+		entry.LatenessMs = late.Milliseconds()
+	}
+}
+
 func isRebootCron(expr string) bool {
 	return strings.EqualFold(strings.TrimSpace(expr), "@reboot")
 }
@@ -174,17 +213,21 @@ func NewCronService() *CronService {
 		entries:       map[string]cron.EntryID{},
 		running:       map[string]map[string]*runningJobInstance{},
 		globalEnabled: true,
+		pruneQueue:    make(chan string, 16),
 	}
 	if runtime.GOOS == "windows" {
 		s.hotkeys = newWindowsHotkeyManager(func(jobID string) {
-			s.runFromSource(jobID, logTriggerSourceHotkey)
+			s.runFromSource(jobID, logTriggerSourceHotkey, time.Time{})
 		})
 		s.hotkeys.Start()
 	}
 
+	// Runs still marked running were cut short when wincron last exited.
+	_ = logs.markInterrupted(time.Now())
 	s.reloadFromDisk()
 	s.syncHotkeysFromJobs()
 	s.scheduler.Start()
+	go s.runLogMaintenance()
 	return s
 }
 
@@ -477,14 +520,61 @@ func (s *CronService) PreviewNextRun(cronExpr string) (string, error) {
 func (s *CronService) UpsertJob(job Job) (Job, error) {
 	job.NextRunAt = ""
 	job.Cron = strings.TrimSpace(job.Cron)
-	if job.Command == "" {
-		return Job{}, errors.New("command is required")
+	job.Shell = normalizeJobShell(job.Shell)
+	steps, err := normalizeJobSteps(job.Steps)
+	if err != nil {
+		return Job{}, err
+	}
+	job.Steps = steps
+	matrix, err := normalizeJobMatrix(job.Matrix)
+	if err != nil {
+		return Job{}, err
+	}
+	if matrix != nil && len(job.Steps) > 0 {
+		return Job{}, errors.New("matrix cannot be combined with steps")
+	}
+	job.Matrix = matrix
+	if job.Shell != "" {
+		if strings.TrimSpace(job.Script) == "" {
+			return Job{}, errors.New("script is required")
+		}
+	} else {
+		job.Script = ""
+		if job.Command == "" && len(job.Steps) == 0 {
+			return Job{}, errors.New("command is required")
+		}
+	}
+	if err := validateSuccessCriteria(&job); err != nil {
+		return Job{}, err
+	}
+	params, err := normalizeJobParams(job.Params)
+	if err != nil {
+		return Job{}, err
+	}
+	job.Params = params
+	job.StdinSource = normalizeStdinSource(job.StdinSource)
+	if job.StdinSource == "" {
+		job.Stdin = ""
 	}
 	if job.Timeout < 0 {
 		job.Timeout = 0
 	}
+	job.StopSignal = normalizeStopSignal(job.StopSignal)
+	if job.StopSignal == "" || job.StopGracePeriod < 0 {
+		job.StopGracePeriod = 0
+	}
+	if encoding, ok := normalizeOutputEncoding(job.OutputEncoding); ok {
+		job.OutputEncoding = encoding
+	} else {
+		return Job{}, fmt.Errorf("unsupported outputEncoding: %s", job.OutputEncoding)
+	}
+	job.AnsiMode = normalizeANSIMode(job.AnsiMode)
+	job.LogRetention = normalizeJobLogRetention(job.LogRetention)
 	if job.Name == "" {
 		job.Name = job.Command
+		if job.Shell != "" {
+			job.Name = renderJobCommandLine(job, nil)
+		}
 	}
 	job.FlagProcessCreation = normalizeProcessCreationFlag(job.FlagProcessCreation)
 	if job.InheritEnv != nil && *job.InheritEnv {
@@ -621,17 +711,43 @@ func (s *CronService) SetJobFolder(id string, folder string) (Job, error) {
 }
 
 func (s *CronService) RunNow(id string) (JobLogEntry, error) {
-	return s.runNow(id, logTriggerSourceUI)
+	return s.runNow(id, runOptions{triggerSource: logTriggerSourceUI})
 }
 
-func (s *CronService) runNow(id string, triggerSource string) (JobLogEntry, error) {
+// RunWithOverrides runs a job once with extra arguments appended to its Args,
+// extra environment variables and, when non-empty, another working directory.
+func (s *CronService) RunWithOverrides(id string, extraArgs []string, env map[string]string, workDir string) (JobLogEntry, error) {
+	overrides, err := newRunOverrides(extraArgs, env, workDir, nil)
+	if err != nil {
+		return JobLogEntry{}, err
+	}
+	return s.runNow(id, runOptions{triggerSource: logTriggerSourceUI, overrides: overrides})
+}
+
+// RunWithParams runs a job once with values for its declared params. Params
+// that are not given use their defaults.
+func (s *CronService) RunWithParams(id string, params map[string]string) (JobLogEntry, error) {
+	overrides, err := newRunOverrides(nil, nil, "", params)
+	if err != nil {
+		return JobLogEntry{}, err
+	}
+	return s.runNow(id, runOptions{triggerSource: logTriggerSourceUI, overrides: overrides})
+}
+
+func (s *CronService) runNow(id string, run runOptions) (JobLogEntry, error) {
 	s.mu.Lock()
 	job, ok := s.jobs[id]
 	s.mu.Unlock()
 	if !ok {
 		return JobLogEntry{}, errors.New("job not found")
 	}
-	entry, err := s.runJobWithPolicy(job, triggerSource)
+	if _, err := resolveJobParams(job.Params, run.overrides.params()); err != nil {
+		return JobLogEntry{}, err
+	}
+	if len(job.Steps) > 0 && run.overrides != nil && len(run.overrides.Args) > 0 {
+		return JobLogEntry{}, errStepsExtraArgs
+	}
+	entry, err := s.runJobWithPolicy(run.overrides.apply(job), run)
 	if err != nil {
 		return JobLogEntry{}, err
 	}
@@ -644,8 +760,53 @@ func (s *CronService) runNow(id string, triggerSource string) (JobLogEntry, erro
 	return *entry, nil
 }
 
+// startRuns runs jobs in the background and reports the ones that could not
+// start within wait. Runs still going by then count as started.
+func (s *CronService) startRuns(jobs []Job, run runOptions, wait time.Duration) error {
+	results := make(chan error, len(jobs))
+	for _, j := range jobs {
+		go func() {
+			entry, err := s.runNow(j.ID, run)
+			if err == nil && entry.Status == logStatusStartFailed {
+				err = errors.New(entry.Error)
+				if entry.Error == "" {
+					err = errors.New(entry.ErrorCode)
+				}
+			}
+			if err != nil {
+				err = fmt.Errorf("%s: %w", j.Name, err)
+			}
+			results <- err
+		}()
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	var errs []error
+	for range jobs {
+		select {
+		case err := <-results:
+			if err != nil {
+				errs = append(errs, err)
+			}
+		case <-timer.C:
+			return errors.Join(errs...)
+		}
+	}
+	return errors.Join(errs...)
+}
+
 func (s *CronService) RunPreview(req PreviewRunRequest) (JobLogEntry, error) {
-	if req.Command == "" {
+	shell := normalizeJobShell(req.Shell)
+	steps, err := normalizeJobSteps(req.Steps)
+	if err != nil {
+		return JobLogEntry{}, err
+	}
+	if shell != "" {
+		if strings.TrimSpace(req.Script) == "" {
+			return JobLogEntry{}, errors.New("script is required")
+		}
+	} else if req.Command == "" && len(steps) == 0 {
 		return JobLogEntry{}, errors.New("command is required")
 	}
 
@@ -657,38 +818,61 @@ func (s *CronService) RunPreview(req PreviewRunRequest) (JobLogEntry, error) {
 	jobName := req.JobName
 	if jobName == "" {
 		jobName = req.Command
+		if shell != "" {
+			jobName = renderScriptCommandLine(shell, req.Script, nil)
+		}
 	}
 
 	job := Job{
-		ID:                  jobID,
-		Name:                jobName,
-		Command:             req.Command,
-		Args:                req.Args,
-		WorkDir:             req.WorkDir,
-		InheritEnv:          req.InheritEnv,
-		FlagProcessCreation: normalizeProcessCreationFlag(req.FlagProcessCreation),
-		Timeout:             req.Timeout,
-		Enabled:             true,
-	}
-
-	entry := s.execute(job, "", logTriggerSourcePreview)
+		ID:                     jobID,
+		Name:                   jobName,
+		Command:                req.Command,
+		Args:                   req.Args,
+		Params:                 req.Params,
+		Steps:                  steps,
+		Matrix:                 req.Matrix,
+		Shell:                  shell,
+		Script:                 req.Script,
+		StdinSource:            normalizeStdinSource(req.StdinSource),
+		Stdin:                  req.Stdin,
+		WorkDir:                req.WorkDir,
+		InheritEnv:             req.InheritEnv,
+		FlagProcessCreation:    normalizeProcessCreationFlag(req.FlagProcessCreation),
+		Timeout:                req.Timeout,
+		StopSignal:             normalizeStopSignal(req.StopSignal),
+		StopGracePeriod:        req.StopGracePeriod,
+		OutputEncoding:         req.OutputEncoding,
+		AnsiMode:               normalizeANSIMode(req.AnsiMode),
+		SuccessExitCodes:       req.SuccessExitCodes,
+		FailIfOutputMatches:    req.FailIfOutputMatches,
+		SucceedIfOutputMatches: req.SucceedIfOutputMatches,
+		Enabled:                true,
+	}
+	if err := validateSuccessCriteria(&job); err != nil {
+		return JobLogEntry{}, err
+	}
+
+	entry := s.execute(job, "", runOptions{triggerSource: logTriggerSourcePreview})
 	if err := s.finishExecution("", entry, false); err != nil {
 		return JobLogEntry{}, err
 	}
 	return entry, nil
 }
 
-func (s *CronService) runningCommands(jobID string) []*exec.Cmd {
+// stopRunningInstances starts stopping the job's running processes and
+// returns channels that are closed once each of them has exited.
+func (s *CronService) stopRunningInstances(jobID string, stopReason string) []<-chan struct{} {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	instances := s.running[jobID]
-	cmds := make([]*exec.Cmd, 0, len(instances))
+	done := make([]<-chan struct{}, 0, len(instances))
 	for _, inst := range instances {
-		if inst != nil && inst.cmd != nil && inst.cmd.Process != nil {
-			cmds = append(cmds, inst.cmd)
+		if inst != nil && inst.hasProcessLocked() {
+			inst.stopLocked(stopReason)
+			done = append(done, inst.done)
 		}
 	}
-	return cmds
+	return done
 }
 
 func (s *CronService) reserveExecutionLocked(jobID string, policy string) (string, bool) {
@@ -723,53 +907,22 @@ func (s *CronService) updateRunningInstance(jobID, instanceID string, update fun
 	s.mu.Unlock()
 }
 
-func (s *CronService) runningLogEntries(jobID string) []JobLogEntry {
+func (s *CronService) releaseRunningInstance(jobID, instanceID string) *runningJobInstance {
+	if instanceID == "" {
+		return nil
+	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
-
-	var entries []JobLogEntry
-	for runningJobID, instances := range s.running {
-		if jobID != "" && runningJobID != jobID {
-			continue
-		}
-		for _, inst := range instances {
-			if inst == nil || inst.entry.ID == "" {
-				continue
-			}
-			entries = append(entries, inst.entry)
-		}
-	}
-	return entries
-}
-
-func appendUniqueLogEntries(dst []JobLogEntry, seen map[string]struct{}, entries []JobLogEntry) []JobLogEntry {
-	for _, entry := range entries {
-		if entry.ID == "" {
-			continue
-		}
-		if _, ok := seen[entry.ID]; ok {
-			continue
-		}
-		seen[entry.ID] = struct{}{}
-		dst = append(dst, entry)
+	instances, ok := s.running[jobID]
+	if !ok {
+		return nil
 	}
-	return dst
-}
-
-func uniqueLogEntries(entries []JobLogEntry) []JobLogEntry {
-	unique := make([]JobLogEntry, 0, len(entries))
-	return appendUniqueLogEntries(unique, make(map[string]struct{}, len(entries)), entries)
-}
-
-func logEntryIDs(entries []JobLogEntry) []string {
-	ids := make([]string, 0, len(entries))
-	for _, entry := range entries {
-		if entry.ID == "" {
-			continue
-		}
-		ids = append(ids, entry.ID)
+	inst := instances[instanceID]
+	delete(instances, instanceID)
+	if len(instances) == 0 {
+		delete(s.running, jobID)
 	}
-	return ids
+	return inst
 }
 
 func (s *CronService) TerminateLogEntry(entryID string) error {
@@ -786,25 +939,24 @@ func (s *CronService) TerminateLogEntry(entryID string) error {
 			if inst == nil || inst.entry.ID != entryID {
 				continue
 			}
-			if inst.cmd == nil || inst.cmd.Process == nil {
+			if !inst.hasProcessLocked() {
 				return errors.New("job process is not running")
 			}
-			return inst.cmd.Process.Kill()
+			inst.stopLocked(logErrorTerminated)
+			return nil
 		}
 	}
 	return errors.New("running job not found")
 }
 
-func (s *CronService) runJobWithPolicy(job Job, triggerSource string) (*JobLogEntry, error) {
+func (s *CronService) runJobWithPolicy(job Job, run runOptions) (*JobLogEntry, error) {
+	triggerSource := run.triggerSource
 	job.ConcurrencyPolicy = normalizeConcurrencyPolicy(job.ConcurrencyPolicy)
 
 	if job.ConcurrencyPolicy == "kill_old" {
-		cmds := s.runningCommands(job.ID)
-		for _, cmd := range cmds {
-			if cmd == nil || cmd.Process == nil {
-				continue
-			}
-			_ = cmd.Process.Kill()
+		// Wait for the old runs to exit so they never overlap the new one.
+		for _, done := range s.stopRunningInstances(job.ID, logErrorKilledByPolicy) {
+			<-done
 		}
 	}
 
@@ -816,75 +968,52 @@ func (s *CronService) runJobWithPolicy(job Job, triggerSource string) (*JobLogEn
 		return nil, errors.New("job is already running")
 	}
 	if alreadyRunning {
+		s.recordSkippedRun(job, run, logErrorAlreadyRunning, "previous run is still running")
 		return nil, nil
 	}
 
-	entry := s.execute(job, instanceID, triggerSource)
+	entry := s.execute(job, instanceID, run)
 	return &entry, nil
 }
 
 func (s *CronService) ListLogs(jobID string, limit int) ([]JobLogEntry, error) {
 	s.logsMu.Lock()
-	logs, err := s.logs.tail(jobID, limit)
-	s.logsMu.Unlock()
-	if err != nil {
-		return nil, err
-	}
-
-	running := s.runningLogEntries(jobID)
-	if len(running) == 0 {
-		return logs, nil
-	}
-
-	combined := make([]JobLogEntry, 0, len(running)+len(logs))
-	seen := make(map[string]struct{}, len(running)+len(logs))
-	combined = appendUniqueLogEntries(combined, seen, running)
-	combined = appendUniqueLogEntries(combined, seen, logs)
-	return combined, nil
+	defer s.logsMu.Unlock()
+	return s.logs.tail(jobID, limit)
 }
 
-func (s *CronService) ListLogsPage(jobID string, offset int, limit int) (JobLogPage, error) {
-	if offset < 0 {
-		offset = 0
-	}
-
-	running := uniqueLogEntries(s.runningLogEntries(jobID))
-
+// ListLogsPage lists stored runs, including those still running, which are
+// stored when they start.
+func (s *CronService) ListLogsPage(jobID string, status string, offset int, limit int) (JobLogPage, error) {
 	s.logsMu.Lock()
-	logs, storedTotalCount, hasMore, err := s.logs.page(jobID, offset, limit)
+	logs, totalCount, hasMore, err := s.logs.page(logFilter{jobID: jobID, status: status}, offset, limit)
+	s.logsMu.Unlock()
 	if err != nil {
-		s.logsMu.Unlock()
 		return JobLogPage{}, err
 	}
+	return JobLogPage{
+		Items:       logs,
+		StoredCount: len(logs),
+		TotalCount:  totalCount,
+		HasMore:     hasMore,
+	}, nil
+}
 
-	runningInStoreCount := 0
-	if len(running) > 0 {
-		runningInStoreCount, err = s.logs.countExistingIDs(jobID, logEntryIDs(running))
-		if err != nil {
-			s.logsMu.Unlock()
-			return JobLogPage{}, err
-		}
-	}
+// ListLogsPageAfter lists the runs after cursor, which is "" for the first
+// page or NextCursor of the previous one.
+func (s *CronService) ListLogsPageAfter(jobID string, status string, cursor string, limit int) (JobLogPage, error) {
+	s.logsMu.Lock()
+	logs, totalCount, hasMore, next, err := s.logs.pageAfter(logFilter{jobID: jobID, status: status}, strings.TrimSpace(cursor), limit)
 	s.logsMu.Unlock()
-
-	totalCount := storedTotalCount + len(running) - runningInStoreCount
-	if totalCount < 0 {
-		totalCount = 0
-	}
-
-	items := logs
-	if offset == 0 && len(running) > 0 {
-		items = make([]JobLogEntry, 0, len(running)+len(logs))
-		seen := make(map[string]struct{}, len(running)+len(logs))
-		items = appendUniqueLogEntries(items, seen, running)
-		items = appendUniqueLogEntries(items, seen, logs)
+	if err != nil {
+		return JobLogPage{}, err
 	}
-
 	return JobLogPage{
-		Items:       items,
+		Items:       logs,
 		StoredCount: len(logs),
 		TotalCount:  totalCount,
 		HasMore:     hasMore,
+		NextCursor:  next,
 	}, nil
 }
 
@@ -894,16 +1023,58 @@ func (s *CronService) appendLog(entry JobLogEntry) error {
 	return s.logs.append(entry)
 }
 
+// startExecution stores entry as running, to be replaced by finishExecution,
+// and tells listeners the run started. The run goes on when storing fails,
+// since finishExecution writes the complete row, but the failure is logged:
+// until then the run is missing from the logs and a crash would leave no
+// interrupted row behind.
+func (s *CronService) startExecution(entry JobLogEntry) {
+	entry.Status = logStatusRunning
+	if err := s.appendLog(entry); err != nil {
+		fmt.Fprintf(os.Stderr, "store running log of %s: %v\n", entry.JobName, err)
+	}
+	s.notifyStarted(entry)
+}
+
+// recordSkippedRun stores a trigger that did not start a run, unless that is
+// turned off in the settings. It does not count as an execution of the job.
+func (s *CronService) recordSkippedRun(job Job, run runOptions, errorCode string, reason string) {
+	s.mu.Lock()
+	disabled := s.skippedRunLogDisabled
+	s.mu.Unlock()
+	if disabled != nil && disabled() {
+		return
+	}
+	now := time.Now()
+	entry := newRunningLogEntry(job, run.triggerSource, now)
+	setLogEntrySchedule(&entry, run.scheduledAt, now)
+	entry.FinishedAt = entry.StartedAt
+	entry.ExitCode = -1
+	entry.Status = logStatusSkipped
+	entry.ErrorCode = errorCode
+	entry.Error = reason
+	if err := s.appendLog(entry); err == nil {
+		s.requestLogPrune(entry.JobID)
+	}
+}
+
+func (s *CronService) setSkippedRunLogDisabled(f func() bool) {
+	s.mu.Lock()
+	s.skippedRunLogDisabled = f
+	s.mu.Unlock()
+}
+
 func (s *CronService) finishExecution(jobID string, entry JobLogEntry, updateState bool) error {
 	jobsChanged := false
 	if updateState {
-		if changed, err := s.applyExecutionResult(jobID, entry.ExitCode == 0, entry.FinishedAt); err == nil {
+		if changed, err := s.applyExecutionResult(jobID, entry.Status == logStatusSuccess, entry.FinishedAt); err == nil {
 			jobsChanged = changed
 		}
 	}
 	if err := s.appendLog(entry); err != nil {
 		return err
 	}
+	s.requestLogPrune(entry.JobID)
 	if jobsChanged {
 		s.notifyJobsChanged()
 	}
@@ -983,17 +1154,30 @@ func (s *CronService) rescheduleLocked(id string) error {
 		return nil
 	}
 
-	jobID := job.ID
-	entryID, err := s.scheduler.AddFunc(expr, func() {
-		s.runFromSource(jobID, logTriggerSourceCron)
-	})
+	schedule, err := s.parser.Parse(expr)
 	if err != nil {
 		return err
 	}
-	s.entries[id] = entryID
+	jobID := job.ID
+	s.entries[id] = s.scheduler.Schedule(schedule, cron.FuncJob(func() {
+		s.runFromSource(jobID, logTriggerSourceCron, s.plannedFireTime(jobID))
+	}))
 	return nil
 }
 
+// plannedFireTime returns the time the scheduler meant to fire the cron entry
+// of jobID at. The scheduler moves Prev there before it answers an Entry
+// call, so a job started by that firing always sees it.
+func (s *CronService) plannedFireTime(jobID string) time.Time {
+	s.mu.Lock()
+	entryID, ok := s.entries[jobID]
+	s.mu.Unlock()
+	if !ok {
+		return time.Time{}
+	}
+	return s.scheduler.Entry(entryID).Prev
+}
+
 func (s *CronService) unscheduleLocked(id string) {
 	entryID, ok := s.entries[id]
 	if !ok {
@@ -1015,23 +1199,31 @@ func (s *CronService) RunStartupJobs() {
 	s.mu.Unlock()
 
 	for _, job := range jobsToRun {
-		go s.runFromSource(job.ID, logTriggerSourceCron)
+		go s.runFromSource(job.ID, logTriggerSourceCron, time.Time{})
 	}
 }
 
-func (s *CronService) runFromSource(id, triggerSource string) {
+// runFromSource runs a job for the scheduler or a hotkey. scheduledAt is the
+// planned fire time of a cron trigger and zero otherwise.
+func (s *CronService) runFromSource(id, triggerSource string, scheduledAt time.Time) {
 	s.mu.Lock()
 	job, ok := s.jobs[id]
 	globalEnabled := s.globalEnabled
 	paused := s.hotkeysPaused
 	s.mu.Unlock()
-	if !ok || !globalEnabled || !job.Enabled {
+	if !ok || !job.Enabled {
+		return
+	}
+	run := runOptions{triggerSource: triggerSource, scheduledAt: scheduledAt}
+	if !globalEnabled {
+		s.recordSkippedRun(job, run, logErrorGloballyDisabled, "WinCron is disabled")
 		return
 	}
 	if triggerSource == logTriggerSourceHotkey && paused {
+		s.recordSkippedRun(job, run, logErrorHotkeysPaused, "hotkeys are paused")
 		return
 	}
-	entry, err := s.runJobWithPolicy(job, triggerSource)
+	entry, err := s.runJobWithPolicy(job, run)
 	if triggerSource == logTriggerSourceCron {
 		s.notifyJobsChanged()
 	}
@@ -1113,19 +1305,92 @@ func (s *CronService) notifyJobsChanged() {
 	go f()
 }
 
-func (s *CronService) execute(job Job, runningInstanceID string, triggerSource string) JobLogEntry {
+func (s *CronService) execute(job Job, runningInstanceID string, run runOptions) JobLogEntry {
 	start := time.Now()
-	entry := newRunningLogEntry(job, triggerSource, start)
+	entry := newRunningLogEntry(job, run.triggerSource, start)
+	setLogEntrySchedule(&entry, run.scheduledAt, start)
+	entry.Overrides = run.overrides
+	runCtx := newRunContext(job, entry, run, start)
+	defer s.releaseRunningInstance(job.ID, runningInstanceID)
 
-	cmd := exec.Command(job.Command, job.Args...)
-	workDir, err := resolveJobWorkDir(job.WorkDir)
+	params, err := resolveJobParams(job.Params, run.overrides.params())
 	if err != nil {
-		end := time.Now()
-		entry.FinishedAt = end.Format(time.RFC3339)
-		entry.ExitCode = -1
-		entry.Error = err.Error()
+		failProcessStart(&entry, logErrorInvalidParams, err)
 		return entry
 	}
+	runCtx.Params = params
+
+	if job.Matrix != nil {
+		s.executeMatrix(job, runningInstanceID, run, runCtx, &entry)
+		return entry
+	}
+	if len(job.Steps) > 0 {
+		s.executeSteps(job, runningInstanceID, run, runCtx, &entry)
+		return entry
+	}
+	s.runProcess(job, run.overrides, runCtx, &entry, func(cmd *exec.Cmd, stopper *processStopper, done <-chan struct{}) {
+		s.updateRunningInstance(job.ID, runningInstanceID, func(inst *runningJobInstance) {
+			inst.cmd = cmd
+			inst.entry = entry
+			inst.stopper = stopper
+			inst.done = done
+		})
+		s.startExecution(entry)
+	})
+	return entry
+}
+
+func failProcessStart(entry *JobLogEntry, errorCode string, err error) {
+	entry.FinishedAt = time.Now().Format(time.RFC3339)
+	entry.ExitCode = -1
+	entry.Status = logStatusStartFailed
+	entry.ErrorCode = errorCode
+	entry.Error = err.Error()
+}
+
+// runProcess starts job's command, waits for it and fills entry with the
+// outcome. started is called once the process is running.
+func (s *CronService) runProcess(job Job, overrides *RunOverrides, runCtx runContext, entry *JobLogEntry, started func(*exec.Cmd, *processStopper, <-chan struct{})) {
+	failStart := func(errorCode string, err error) {
+		failProcessStart(entry, errorCode, err)
+	}
+
+	args, err := runCtx.renderAll(job.Args)
+	if err != nil {
+		failStart(logErrorInvalidTemplate, fmt.Errorf("render args: %w", err))
+		return
+	}
+	entry.CommandLine = renderJobCommandLine(job, args)
+	workDir, err := runCtx.render(job.WorkDir)
+	if err != nil {
+		failStart(logErrorInvalidTemplate, fmt.Errorf("render workDir: %w", err))
+		return
+	}
+	workDir, err = resolveJobWorkDir(workDir)
+	if err != nil {
+		failStart(logErrorWorkDirNotFound, err)
+		return
+	}
+	if info, err := os.Stat(workDir); err != nil {
+		failStart(logErrorWorkDirNotFound, err)
+		return
+	} else if !info.IsDir() {
+		failStart(logErrorWorkDirNotFound, fmt.Errorf("workDir is not a directory: %s", workDir))
+		return
+	}
+
+	command := job.Command
+	if isShellJob(job) {
+		scriptPath, err := writeJobScript(job.Shell, job.Script)
+		if err != nil {
+			failStart(logErrorScriptWriteFailed, fmt.Errorf("write script: %w", err))
+			return
+		}
+		defer os.Remove(scriptPath)
+		command, args = shellCommand(job, scriptPath, args)
+	}
+
+	cmd := exec.Command(command, args...)
 	cmd.Dir = workDir
 	inheritEnv := true
 	if job.InheritEnv != nil {
@@ -1136,9 +1401,24 @@ func (s *CronService) execute(job Job, runningInstanceID string, triggerSource s
 	} else {
 		cmd.Env = []string{}
 	}
+	cmd.Env = append(cmd.Env, runCtx.env()...)
+	overrideEnv, err := overrides.env(runCtx)
+	if err != nil {
+		failStart(logErrorInvalidTemplate, err)
+		return
+	}
+	cmd.Env = append(cmd.Env, overrideEnv...)
 	applyJobWindowsProcessOptions(cmd, job)
+	prepareProcessTree(cmd)
+	prepareStopSignal(cmd, job)
 
-	s.updateRunningInstance(job.ID, runningInstanceID, func(inst *runningJobInstance) { inst.cmd = cmd })
+	stdin, closeStdin, err := s.openJobStdin(job)
+	if err != nil {
+		failStart(logErrorStdinUnavailable, fmt.Errorf("open stdin: %w", err))
+		return
+	}
+	defer closeStdin()
+	cmd.Stdin = stdin
 
 	var outBuf bytes.Buffer
 	var errBuf bytes.Buffer
@@ -1146,62 +1426,87 @@ func (s *CronService) execute(job Job, runningInstanceID string, triggerSource s
 	cmd.Stderr = &errBuf
 
 	runErr := cmd.Start()
-	timedOut := false
-	if runErr == nil {
-		s.updateRunningInstance(job.ID, runningInstanceID, func(inst *runningJobInstance) { inst.entry = entry })
-		s.notifyStarted(entry)
-		if job.Timeout > 0 {
-			done := make(chan error, 1)
-			go func() {
-				done <- cmd.Wait()
-			}()
-			select {
-			case runErr = <-done:
-			case <-time.After(time.Duration(job.Timeout) * time.Second):
-				timedOut = true
-				if cmd.Process != nil {
-					_ = cmd.Process.Kill()
-				}
-				runErr = <-done
-			}
-		} else {
-			runErr = cmd.Wait()
+	if runErr != nil {
+		failStart(classifyStartError(runErr), runErr)
+		return
+	}
+	processStart := time.Now()
+	tree, err := attachProcessTree(cmd)
+	if err == nil {
+		defer tree.close()
+	}
+	done := make(chan struct{})
+	stopper := newProcessStopper(cmd, tree, job, done)
+	started(cmd, stopper, done)
+
+	waitErr := make(chan error, 1)
+	go func() {
+		waitErr <- cmd.Wait()
+		close(done)
+	}()
+	if job.Timeout > 0 {
+		select {
+		case runErr = <-waitErr:
+		case <-time.After(time.Duration(job.Timeout) * time.Second):
+			stopper.stop(logErrorTimeout)
+			runErr = <-waitErr
 		}
+	} else {
+		runErr = <-waitErr
+	}
+
+	end := time.Now()
+	stopReason, stopStage := stopper.result()
+
+	entry.DurationMs = end.Sub(processStart).Milliseconds()
+	if state := cmd.ProcessState; state != nil {
+		user, system := processCPUTime(state, tree)
+		entry.UserCPUMs = user.Milliseconds()
+		entry.SystemCPUMs = system.Milliseconds()
+		entry.PeakMemoryBytes = processPeakMemory(state, tree)
 	}
 
 	exitCode := 0
 	errText := ""
-	if timedOut {
+	status := ""
+	errorCode := ""
+	if stopReason == logErrorTimeout {
 		exitCode = -1
 		errText = fmt.Sprintf("timeout after %ds", job.Timeout)
+		status, errorCode = logStatusTimeout, logErrorTimeout
 	} else if runErr != nil {
 		errText = runErr.Error()
 		exitCode = -1
 		var ee *exec.ExitError
 		if errors.As(runErr, &ee) {
 			exitCode = ee.ExitCode()
+		} else {
+			status, errorCode = logStatusFailed, logErrorWaitFailed
 		}
 	}
+	outputEncoding, _ := normalizeOutputEncoding(job.OutputEncoding)
+	fallbackEncoding := systemOutputEncoding()
+	stdout, stdoutSpans := processANSI(decodeOutput(outBuf.Bytes(), outputEncoding, fallbackEncoding), job.AnsiMode)
+	stderr, stderrSpans := processANSI(decodeOutput(errBuf.Bytes(), outputEncoding, fallbackEncoding), job.AnsiMode)
 
-	end := time.Now()
-
-	if runningInstanceID != "" {
-		s.mu.Lock()
-		if instances, ok := s.running[job.ID]; ok {
-			delete(instances, runningInstanceID)
-			if len(instances) == 0 {
-				delete(s.running, job.ID)
-			}
-		}
-		s.mu.Unlock()
+	// Output patterns see the decoded text the log shows, before it is cut.
+	if stopReason != "" && stopReason != logErrorTimeout {
+		status, errorCode = logStatusKilled, stopReason
+	} else if status == "" {
+		status, errorCode = evaluateExitStatus(job, exitCode, stdout, stderr)
 	}
 
+	entry.Stdout = truncateString(stdout, 16*1024)
+	entry.Stderr = truncateString(stderr, 16*1024)
+	entry.StdoutSpans = clipOutputSpans(stdoutSpans, 16*1024)
+	entry.StderrSpans = clipOutputSpans(stderrSpans, 16*1024)
+
 	entry.FinishedAt = end.Format(time.RFC3339)
 	entry.ExitCode = exitCode
-	entry.Stdout = truncateString(outBuf.String(), 16*1024)
-	entry.Stderr = truncateString(errBuf.String(), 16*1024)
+	entry.Status = status
+	entry.ErrorCode = errorCode
+	entry.StopStage = stopStage
 	entry.Error = errText
-	return entry
 }
 
 func truncateString(s string, max int) string {
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	jobShellCmd        = "cmd"
+	jobShellPowerShell = "powershell"
+	jobShellPwsh       = "pwsh"
+	jobShellSh         = "sh"
+	jobShellBash       = "bash"
+)
+
+type jobShellSpec struct {
+	exe  string
+	ext  string
+	bom  bool
+	crlf bool
+	args func(scriptPath string) []string
+}
+
+var jobShellSpecs = map[string]jobShellSpec{
+	jobShellCmd: {
+		exe:  "cmd.exe",
+		ext:  ".cmd",
+		crlf: true,
+		args: func(scriptPath string) []string { return []string{"/d", "/c", scriptPath} },
+	},
+	// Windows PowerShell reads BOM-less scripts in the ANSI code page.
+	jobShellPowerShell: {
+		exe:  "powershell.exe",
+		ext:  ".ps1",
+		bom:  true,
+		crlf: true,
+		args: func(scriptPath string) []string {
+			return []string{"-NoProfile", "-NonInteractive", "-ExecutionPolicy", "Bypass", "-File", scriptPath}
+		},
+	},
+	jobShellPwsh: {
+		exe:  "pwsh",
+		ext:  ".ps1",
+		bom:  true,
+		crlf: true,
+		args: func(scriptPath string) []string {
+			return []string{"-NoProfile", "-NonInteractive", "-ExecutionPolicy", "Bypass", "-File", scriptPath}
+		},
+	},
+	jobShellSh: {
+		exe:  "sh",
+		ext:  ".sh",
+		args: func(scriptPath string) []string { return []string{scriptPath} },
+	},
+	jobShellBash: {
+		exe:  "bash",
+		ext:  ".sh",
+		args: func(scriptPath string) []string { return []string{scriptPath} },
+	},
+}
+
+func normalizeJobShell(value string) string {
+	v := strings.ToLower(strings.TrimSpace(value))
+	if _, ok := jobShellSpecs[v]; ok {
+		return v
+	}
+	return ""
+}
+
+func isShellJob(job Job) bool {
+	return normalizeJobShell(job.Shell) != ""
+}
+
+func encodeJobScript(spec jobShellSpec, script string) []byte {
+	text := strings.ReplaceAll(script, "\r\n", "\n")
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	if spec.crlf {
+		text = strings.ReplaceAll(text, "\n", "\r\n")
+	}
+	// cmd.exe parses batch files in the OEM code page; switch the console to
+	// UTF-8 first so non-ASCII literals survive.
+	if spec.exe == "cmd.exe" && !isASCII(text) {
+		text = "@chcp 65001 >nul\r\n" + text
+	}
+	if spec.bom {
+		return append([]byte{0xEF, 0xBB, 0xBF}, text...)
+	}
+	return []byte(text)
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// writeJobScript stores the script in a temp file with the extension the
+// interpreter expects. The caller removes the file once the run finishes.
+func writeJobScript(shell string, script string) (string, error) {
+	spec, ok := jobShellSpecs[normalizeJobShell(shell)]
+	if !ok {
+		return "", fmt.Errorf("unsupported shell: %s", shell)
+	}
+	if strings.TrimSpace(script) == "" {
+		return "", errors.New("script is required")
+	}
+	f, err := os.CreateTemp("", "wincron-*"+spec.ext)
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Write(encodeJobScript(spec, script)); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// shellCommand returns the interpreter invocation for a script job. A
+// non-empty Command overrides the interpreter executable, e.g. to pick a
+// specific bash.exe.
+func shellCommand(job Job, scriptPath string, args []string) (string, []string) {
+	spec := jobShellSpecs[normalizeJobShell(job.Shell)]
+	exe := strings.TrimSpace(job.Command)
+	if exe == "" {
+		exe = spec.exe
+	}
+	return exe, append(spec.args(scriptPath), args...)
+}
+
+func renderScriptCommandLine(shell string, script string, args []string) string {
+	lines := strings.Split(strings.TrimSpace(strings.ReplaceAll(script, "\r\n", "\n")), "\n")
+	summary := strings.TrimSpace(lines[0])
+	if len(lines) > 1 {
+		summary += fmt.Sprintf(" … (+%d lines)", len(lines)-1)
+	}
+	return renderCommandLine("["+normalizeJobShell(shell)+"] "+summary, args)
+}
+
+func renderJobCommandLine(job Job, args []string) string {
+	if len(job.Steps) > 0 {
+		return renderStepsCommandLine(job)
+	}
+	if isShellJob(job) {
+		return renderScriptCommandLine(job.Shell, job.Script, args)
+	}
+	return renderCommandLine(job.Command, args)
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// JobStats summarizes the stored runs of a job. Skipped and still running
+// runs are counted in ByStatus only; every other figure is about finished
+// runs. The lateness figures cover cron runs, whose planned fire time is
+// known.
+type JobStats struct {
+	JobID                string         `json:"jobId"`
+	JobName              string         `json:"jobName,omitempty"`
+	From                 string         `json:"from,omitempty"`
+	To                   string         `json:"to,omitempty"`
+	Runs                 int            `json:"runs"`
+	Succeeded            int            `json:"succeeded"`
+	Failed               int            `json:"failed"`
+	SuccessRate          float64        `json:"successRate"`
+	AvgDurationMs        int64          `json:"avgDurationMs"`
+	P50DurationMs        int64          `json:"p50DurationMs"`
+	P95DurationMs        int64          `json:"p95DurationMs"`
+	LongestRun           *JobStatsRun   `json:"longestRun,omitempty"`
+	LongestFailureStreak int            `json:"longestFailureStreak"`
+	CurrentFailureStreak int            `json:"currentFailureStreak"`
+	ScheduledRuns        int            `json:"scheduledRuns"`
+	LateRuns             int            `json:"lateRuns"`
+	AvgLatenessMs        int64          `json:"avgLatenessMs"`
+	P95LatenessMs        int64          `json:"p95LatenessMs"`
+	MaxLatenessMs        int64          `json:"maxLatenessMs"`
+	ConsistentlyLate     bool           `json:"consistentlyLate"`
+	ByStatus             map[string]int `json:"byStatus"`
+	ByTriggerSource      map[string]int `json:"byTriggerSource"`
+	Daily                []JobStatsDay  `json:"daily"`
+}
+
+type JobStatsRun struct {
+	ID         string `json:"id"`
+	StartedAt  string `json:"startedAt"`
+	DurationMs int64  `json:"durationMs"`
+	Status     string `json:"status"`
+}
+
+// JobStatsDay counts runs per local calendar day.
+type JobStatsDay struct {
+	Date      string `json:"date"`
+	Runs      int    `json:"runs"`
+	Succeeded int    `json:"succeeded"`
+	Failed    int    `json:"failed"`
+}
+
+// A cron run starting more than lateRunThreshold after its planned time is
+// late. A job is consistently late when at least half of its cron runs are,
+// given at least consistentlyLateMinRuns of them.
+const (
+	lateRunThreshold        = time.Minute
+	consistentlyLateMinRuns = 5
+)
+
+// GetJobStats computes run statistics from the log database. from and to take
+// RFC 3339 times or dates and may be empty for an open range. An empty jobID
+// covers all jobs.
+func (s *CronService) GetJobStats(jobID string, from string, to string) (JobStats, error) {
+	filter := logFilter{jobID: strings.TrimSpace(jobID)}
+	var err error
+	if filter.fromMs, err = parseLogSearchTime(from, false); err != nil {
+		return JobStats{}, err
+	}
+	if filter.toMs, err = parseLogSearchTime(to, true); err != nil {
+		return JobStats{}, err
+	}
+	if filter.fromMs > 0 && filter.toMs > 0 && filter.toMs < filter.fromMs {
+		return JobStats{}, errors.New("to is before from")
+	}
+
+	s.logsMu.Lock()
+	runs, err := s.logs.statsRuns(filter)
+	s.logsMu.Unlock()
+	if err != nil {
+		return JobStats{}, err
+	}
+
+	stats := computeJobStats(runs)
+	stats.JobID = filter.jobID
+	s.mu.Lock()
+	stats.JobName = s.jobs[filter.jobID].Name
+	s.mu.Unlock()
+	if filter.fromMs > 0 {
+		stats.From = unixMsToRFC3339(filter.fromMs)
+	}
+	if filter.toMs > 0 {
+		stats.To = unixMsToRFC3339(filter.toMs)
+	}
+	return stats, nil
+}
+
+type statsRun struct {
+	id            string
+	startedAtMs   int64
+	durationMs    int64
+	status        string
+	triggerSource string
+	scheduled     bool
+	latenessMs    int64
+}
+
+// statsRuns returns the runs matching filter, oldest first.
+func (s *logStore) statsRuns(filter logFilter) ([]statsRun, error) {
+	if err := s.ensureInit(); err != nil {
+		return nil, err
+	}
+	where, args := filter.where()
+	rows, err := s.db.Query(`SELECT id, started_at, finished_at, duration_ms, status, exit_code, trigger_source,
+			scheduled_at, lateness_ms
+		FROM job_logs`+where+`
+		ORDER BY started_at ASC;`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []statsRun
+	for rows.Next() {
+		var (
+			r           statsRun
+			finishedAt  int64
+			exitCode    int
+			scheduledAt int64
+		)
+		if err := rows.Scan(&r.id, &r.startedAtMs, &finishedAt, &r.durationMs, &r.status, &exitCode, &r.triggerSource,
+			&scheduledAt, &r.latenessMs); err != nil {
+			return nil, err
+		}
+		r.scheduled = scheduledAt > 0
+		r.durationMs = logDurationMs(r.durationMs, r.startedAtMs, finishedAt)
+		r.status = normalizeLogStatus(r.status, exitCode)
+		runs = append(runs, r)
+	}
+	return runs, rows.Err()
+}
+
+func computeJobStats(runs []statsRun) JobStats {
+	stats := JobStats{
+		ByStatus:        map[string]int{},
+		ByTriggerSource: map[string]int{},
+		Daily:           []JobStatsDay{},
+	}
+	var (
+		durations []int64
+		total     int64
+		lateness  []int64
+		lateTotal int64
+		streak    int
+		days      = map[string]int{}
+	)
+	for _, r := range runs {
+		stats.ByStatus[r.status]++
+		if r.status == logStatusSkipped || r.status == logStatusRunning {
+			continue
+		}
+		stats.Runs++
+		source := r.triggerSource
+		if source == "" {
+			source = "unknown"
+		}
+		stats.ByTriggerSource[source]++
+
+		date := time.UnixMilli(r.startedAtMs).Format("2006-01-02")
+		i, ok := days[date]
+		if !ok {
+			i = len(stats.Daily)
+			days[date] = i
+			stats.Daily = append(stats.Daily, JobStatsDay{Date: date})
+		}
+		stats.Daily[i].Runs++
+
+		if r.status == logStatusSuccess {
+			stats.Succeeded++
+			stats.Daily[i].Succeeded++
+			streak = 0
+		} else {
+			stats.Failed++
+			stats.Daily[i].Failed++
+			streak++
+			if streak > stats.LongestFailureStreak {
+				stats.LongestFailureStreak = streak
+			}
+		}
+
+		durations = append(durations, r.durationMs)
+		total += r.durationMs
+		if r.scheduled {
+			lateness = append(lateness, r.latenessMs)
+			lateTotal += r.latenessMs
+			if r.latenessMs > lateRunThreshold.Milliseconds() {
+				stats.LateRuns++
+			}
+		}
+		if stats.LongestRun == nil || r.durationMs > stats.LongestRun.DurationMs {
+			stats.LongestRun = &JobStatsRun{
+				ID:         r.id,
+				StartedAt:  unixMsToRFC3339(r.startedAtMs),
+				DurationMs: r.durationMs,
+				Status:     r.status,
+			}
+		}
+	}
+	stats.CurrentFailureStreak = streak
+	if stats.Runs == 0 {
+		return stats
+	}
+
+	stats.SuccessRate = float64(stats.Succeeded) / float64(stats.Runs)
+	stats.AvgDurationMs = total / int64(len(durations))
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	stats.P50DurationMs = percentile(durations, 50)
+	stats.P95DurationMs = percentile(durations, 95)
+
+	if stats.ScheduledRuns = len(lateness); stats.ScheduledRuns > 0 {
+		stats.AvgLatenessMs = lateTotal / int64(len(lateness))
+		sort.Slice(lateness, func(i, j int) bool { return lateness[i] < lateness[j] })
+		stats.P95LatenessMs = percentile(lateness, 95)
+		stats.MaxLatenessMs = lateness[len(lateness)-1]
+		stats.ConsistentlyLate = stats.ScheduledRuns >= consistentlyLateMinRuns && stats.LateRuns*2 >= stats.ScheduledRuns
+	}
+	return stats
+}
+
+// percentile uses the nearest-rank method on sorted values.
+func percentile(sorted []int64, p int) int64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
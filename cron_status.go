@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+const (
+	logStatusSuccess     = "success"
+	logStatusFailed      = "failed"
+	logStatusTimeout     = "timeout"
+	logStatusKilled      = "killed"
+	logStatusStartFailed = "start_failed"
+	logStatusSkipped     = "skipped"
+	logStatusRunning     = "running"
+	logStatusInterrupted = "interrupted"
+)
+
+const (
+	logErrorExitCode           = "exit_code"
+	logErrorOutputMatched      = "output_matched"
+	logErrorTimeout            = "timeout"
+	logErrorKilledByPolicy     = "killed_by_policy"
+	logErrorTerminated         = "terminated"
+	logErrorExecutableNotFound = "executable_not_found"
+	logErrorPermissionDenied   = "permission_denied"
+	logErrorWorkDirNotFound    = "workdir_not_found"
+	logErrorInvalidTemplate    = "invalid_template"
+	logErrorInvalidParams      = "invalid_params"
+	logErrorMatrixUnavailable  = "matrix_unavailable"
+	logErrorStdinUnavailable   = "stdin_unavailable"
+	logErrorScriptWriteFailed  = "script_write_failed"
+	logErrorStartFailed        = "start_error"
+	logErrorWaitFailed         = "wait_error"
+	logErrorInterrupted        = "interrupted"
+	logErrorAlreadyRunning     = "already_running"
+	logErrorGloballyDisabled   = "globally_disabled"
+	logErrorHotkeysPaused      = "hotkeys_paused"
+)
+
+func isLogStatus(status string) bool {
+	switch status {
+	case logStatusSuccess, logStatusFailed, logStatusTimeout, logStatusKilled, logStatusStartFailed, logStatusSkipped,
+		logStatusRunning, logStatusInterrupted:
+		return true
+	default:
+		return false
+	}
+}
+
+// normalizeLogStatus falls back to the exit code for rows written before the
+// status column existed.
+func normalizeLogStatus(status string, exitCode int) string {
+	if status = strings.ToLower(strings.TrimSpace(status)); isLogStatus(status) {
+		return status
+	}
+	if exitCode == 0 {
+		return logStatusSuccess
+	}
+	return logStatusFailed
+}
+
+func normalizeLogStatusFilter(status string) string {
+	if status = strings.ToLower(strings.TrimSpace(status)); isLogStatus(status) {
+		return status
+	}
+	return ""
+}
+
+// classifyStartError maps an error from exec.Cmd.Start to an error code.
+func classifyStartError(err error) string {
+	switch {
+	case errors.Is(err, exec.ErrNotFound), errors.Is(err, os.ErrNotExist):
+		return logErrorExecutableNotFound
+	case errors.Is(err, os.ErrPermission):
+		return logErrorPermissionDenied
+	default:
+		return logErrorStartFailed
+	}
+}
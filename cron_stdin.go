@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	stdinSourceText = "text"
+	stdinSourceFile = "file"
+	stdinSourceJob  = "job"
+)
+
+func normalizeStdinSource(value string) string {
+	v := strings.ToLower(strings.TrimSpace(value))
+	if v == stdinSourceText || v == stdinSourceFile || v == stdinSourceJob {
+		return v
+	}
+	return ""
+}
+
+// openJobStdin returns the reader for the job's stdin, or nil when the job has
+// no stdin configured. The closer must be called once the process exits.
+func (s *CronService) openJobStdin(job Job) (io.Reader, func(), error) {
+	noop := func() {}
+	switch normalizeStdinSource(job.StdinSource) {
+	case stdinSourceText:
+		return strings.NewReader(job.Stdin), noop, nil
+	case stdinSourceFile:
+		path := strings.TrimSpace(job.Stdin)
+		if path == "" {
+			return nil, noop, errors.New("stdin file path is required")
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, noop, err
+		}
+		return f, func() { _ = f.Close() }, nil
+	case stdinSourceJob:
+		output, err := s.previousJobOutput(job.Stdin)
+		if err != nil {
+			return nil, noop, err
+		}
+		return strings.NewReader(output), noop, nil
+	default:
+		return nil, noop, nil
+	}
+}
+
+// previousJobOutput returns the stdout of the latest run of the job matching
+// ref by ID, falling back to name since IDs change on import.
+func (s *CronService) previousJobOutput(ref string) (string, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return "", errors.New("stdin job is required")
+	}
+
+	s.mu.Lock()
+	jobID := ""
+	if _, ok := s.jobs[ref]; ok {
+		jobID = ref
+	} else {
+		for id, j := range s.jobs {
+			if strings.EqualFold(strings.TrimSpace(j.Name), ref) {
+				jobID = id
+				break
+			}
+		}
+	}
+	s.mu.Unlock()
+	if jobID == "" {
+		return "", fmt.Errorf("stdin job not found: %s", ref)
+	}
+
+	s.logsMu.Lock()
+	logs, err := s.logs.lastFinished(jobID)
+	s.logsMu.Unlock()
+	if err != nil {
+		return "", err
+	}
+	if len(logs) == 0 {
+		return "", fmt.Errorf("stdin job has no previous run: %s", ref)
+	}
+	return logs[0].Stdout, nil
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// JobStep is one command of a multi-step job. Steps run in order inside a
+// single run; a failing step ends the run unless ContinueOnError is set.
+type JobStep struct {
+	Name            string   `json:"name,omitempty" yaml:"name,omitempty"`
+	Command         string   `json:"command,omitempty" yaml:"command,omitempty"`
+	Args            []string `json:"args,omitempty" yaml:"args,omitempty"`
+	Shell           string   `json:"shell,omitempty" yaml:"shell,omitempty"`
+	Script          string   `json:"script,omitempty" yaml:"script,omitempty"`
+	WorkDir         string   `json:"workDir,omitempty" yaml:"workDir,omitempty"`
+	Timeout         int      `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	ContinueOnError bool     `json:"continueOnError,omitempty" yaml:"continueOnError,omitempty"`
+}
+
+// JobStepResult is the outcome of one step, stored as a child record of the
+// run's JobLogEntry.
+type JobStepResult struct {
+	Index       int    `json:"index"`
+	Name        string `json:"name"`
+	CommandLine string `json:"commandLine"`
+	StartedAt   string `json:"startedAt"`
+	FinishedAt  string `json:"finishedAt"`
+	ExitCode    int    `json:"exitCode"`
+	Status      string `json:"status"`
+	ErrorCode   string `json:"errorCode,omitempty"`
+	DurationMs  int64  `json:"durationMs"`
+	Stdout      string `json:"stdout"`
+	Stderr      string `json:"stderr"`
+	Error       string `json:"error"`
+}
+
+func normalizeJobSteps(steps []JobStep) ([]JobStep, error) {
+	if len(steps) == 0 {
+		return nil, nil
+	}
+	out := make([]JobStep, 0, len(steps))
+	for i, step := range steps {
+		step.Name = strings.TrimSpace(step.Name)
+		step.Shell = normalizeJobShell(step.Shell)
+		if step.Shell != "" {
+			if strings.TrimSpace(step.Script) == "" {
+				return nil, fmt.Errorf("step %d: script is required", i+1)
+			}
+		} else {
+			step.Script = ""
+			if strings.TrimSpace(step.Command) == "" {
+				return nil, fmt.Errorf("step %d: command is required", i+1)
+			}
+		}
+		if step.Timeout < 0 {
+			step.Timeout = 0
+		}
+		out = append(out, step)
+	}
+	return out, nil
+}
+
+func jobStepName(step JobStep, index int) string {
+	if step.Name != "" {
+		return step.Name
+	}
+	return fmt.Sprintf("step %d", index+1)
+}
+
+// stepJob turns a step into a job that inherits everything else from the
+// parent. Only the first step reads the job's stdin.
+func stepJob(job Job, step JobStep, index int) Job {
+	j := job
+	j.Command = step.Command
+	j.Args = step.Args
+	j.Shell = normalizeJobShell(step.Shell)
+	j.Script = step.Script
+	if strings.TrimSpace(step.WorkDir) != "" {
+		j.WorkDir = step.WorkDir
+	}
+	j.Timeout = step.Timeout
+	j.Steps = nil
+	if index > 0 {
+		j.StdinSource, j.Stdin = "", ""
+	}
+	return j
+}
+
+func renderStepsCommandLine(job Job) string {
+	parts := make([]string, 0, len(job.Steps))
+	for i, step := range job.Steps {
+		parts = append(parts, renderJobCommandLine(stepJob(job, step, i), step.Args))
+	}
+	return strings.Join(parts, " && ")
+}
+
+func (s *CronService) runningStopReason(jobID, instanceID string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if inst := s.running[jobID][instanceID]; inst != nil {
+		return inst.stopReason
+	}
+	return ""
+}
+
+func (s *CronService) stopRunningInstance(jobID, instanceID string, reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if inst := s.running[jobID][instanceID]; inst != nil {
+		inst.stopLocked(reason)
+	}
+}
+
+// executeSteps runs the steps of job in order and folds their results into
+// entry. job.Timeout bounds the whole run; each step may set its own.
+func (s *CronService) executeSteps(job Job, runningInstanceID string, run runOptions, runCtx runContext, entry *JobLogEntry) {
+	start := time.Now()
+	runDone := make(chan struct{})
+	defer close(runDone)
+	s.updateRunningInstance(job.ID, runningInstanceID, func(inst *runningJobInstance) {
+		inst.entry = *entry
+		inst.done = runDone
+	})
+	s.startExecution(*entry)
+
+	if job.Timeout > 0 {
+		timer := time.AfterFunc(time.Duration(job.Timeout)*time.Second, func() {
+			s.stopRunningInstance(job.ID, runningInstanceID, logErrorTimeout)
+		})
+		defer timer.Stop()
+	}
+
+	var (
+		stdout  strings.Builder
+		stderr  strings.Builder
+		failure *JobStepResult
+	)
+	results := make([]JobStepResult, 0, len(job.Steps))
+	entry.Status, entry.ErrorCode = logStatusSuccess, ""
+	for i, step := range job.Steps {
+		result := JobStepResult{Index: i, Name: jobStepName(step, i)}
+		if failure != nil || s.runningStopReason(job.ID, runningInstanceID) != "" {
+			result.CommandLine = renderJobCommandLine(stepJob(job, step, i), step.Args)
+			result.Status = logStatusSkipped
+			result.ExitCode = -1
+			results = append(results, result)
+			continue
+		}
+
+		stepEntry := JobLogEntry{StartedAt: time.Now().Format(time.RFC3339)}
+		s.runProcess(stepJob(job, step, i), run.overrides, runCtx, &stepEntry, func(cmd *exec.Cmd, stopper *processStopper, _ <-chan struct{}) {
+			s.updateRunningInstance(job.ID, runningInstanceID, func(inst *runningJobInstance) {
+				inst.cmd = cmd
+				inst.stopper = stopper
+				if inst.stopReason != "" {
+					stopper.stop(inst.stopReason)
+				}
+			})
+		})
+
+		result.CommandLine = stepEntry.CommandLine
+		result.StartedAt = stepEntry.StartedAt
+		result.FinishedAt = stepEntry.FinishedAt
+		result.ExitCode = stepEntry.ExitCode
+		result.Status = stepEntry.Status
+		result.ErrorCode = stepEntry.ErrorCode
+		result.DurationMs = stepEntry.DurationMs
+		result.Stdout = stepEntry.Stdout
+		result.Stderr = stepEntry.Stderr
+		result.Error = stepEntry.Error
+		if result.ErrorCode == logErrorTimeout && step.Timeout == 0 {
+			result.Error = "stopped by the job timeout"
+		}
+		results = append(results, result)
+
+		entry.ExitCode = stepEntry.ExitCode
+		entry.UserCPUMs += stepEntry.UserCPUMs
+		entry.SystemCPUMs += stepEntry.SystemCPUMs
+		if stepEntry.PeakMemoryBytes > entry.PeakMemoryBytes {
+			entry.PeakMemoryBytes = stepEntry.PeakMemoryBytes
+		}
+		if stepEntry.StopStage != "" {
+			entry.StopStage = stepEntry.StopStage
+		}
+		header := fmt.Sprintf("%d/%s", result.Index+1, result.Name)
+		writeSectionOutput(&stdout, header, result.Stdout)
+		writeSectionOutput(&stderr, header, result.Stderr)
+
+		if result.Status != logStatusSuccess && !step.ContinueOnError {
+			failure = &results[len(results)-1]
+		}
+	}
+
+	if reason := s.runningStopReason(job.ID, runningInstanceID); reason == logErrorTimeout {
+		entry.Status, entry.ErrorCode = logStatusTimeout, logErrorTimeout
+		entry.Error = fmt.Sprintf("timeout after %ds", job.Timeout)
+	} else if reason != "" {
+		entry.Status, entry.ErrorCode = logStatusKilled, reason
+	} else if failure != nil {
+		entry.Status, entry.ErrorCode = failure.Status, failure.ErrorCode
+		entry.Error = fmt.Sprintf("%s failed: %s", failure.Name, failure.Error)
+		if failure.Error == "" {
+			entry.Error = fmt.Sprintf("%s failed with exit code %d", failure.Name, failure.ExitCode)
+		}
+	}
+	if entry.Status != logStatusSuccess && entry.ExitCode == 0 {
+		entry.ExitCode = -1
+	}
+
+	end := time.Now()
+	entry.Steps = results
+	entry.FinishedAt = end.Format(time.RFC3339)
+	entry.DurationMs = end.Sub(start).Milliseconds()
+	entry.Stdout = truncateString(stdout.String(), 16*1024)
+	entry.Stderr = truncateString(stderr.String(), 16*1024)
+}
+
+// writeSectionOutput appends output under a "[header]" line, used to combine
+// the output of steps and matrix items into the parent entry.
+func writeSectionOutput(b *strings.Builder, header string, output string) {
+	if output == "" {
+		return
+	}
+	fmt.Fprintf(b, "[%s]\n", header)
+	b.WriteString(output)
+	if !strings.HasSuffix(output, "\n") {
+		b.WriteByte('\n')
+	}
+}
+
+// errStepsExtraArgs is returned for one-off extra args on a multi-step job,
+// where it is unclear which step they belong to.
+var errStepsExtraArgs = errors.New("extra args are not supported for multi-step jobs; use params instead")
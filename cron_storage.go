@@ -1,10 +1,13 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -63,8 +66,9 @@ func (s *logStore) append(entry JobLogEntry) error {
 
 	startedAtMs := parseRFC3339ToUnixMs(entry.StartedAt)
 	finishedAtMs := parseRFC3339ToUnixMs(entry.FinishedAt)
+	stdout, stderr, compression, outputSize := encodeLogOutput(entry.Stdout, entry.Stderr)
 
-	_, err := s.insertStmt.Exec(
+	values := []any{
 		entry.ID,
 		entry.JobID,
 		entry.JobName,
@@ -73,17 +77,103 @@ func (s *logStore) append(entry JobLogEntry) error {
 		startedAtMs,
 		finishedAtMs,
 		entry.ExitCode,
-		entry.Stdout,
-		entry.Stderr,
+		normalizeLogStatus(entry.Status, entry.ExitCode),
+		strings.TrimSpace(entry.ErrorCode),
+		entry.StopStage,
+		entry.DurationMs,
+		entry.UserCPUMs,
+		entry.SystemCPUMs,
+		entry.PeakMemoryBytes,
+		stdout,
+		stderr,
+		encodeOutputSpans(entry.StdoutSpans),
+		encodeOutputSpans(entry.StderrSpans),
+		encodeRunOverrides(entry.Overrides),
 		entry.Error,
-	)
-	return err
+		entry.ParentID,
+		entry.Item,
+		parseRFC3339ToUnixMs(entry.ScheduledAt),
+		entry.LatenessMs,
+		compression,
+		outputSize,
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	if _, err := tx.Stmt(s.insertStmt).Exec(values...); err != nil {
+		return err
+	}
+	for _, step := range entry.Steps {
+		stdout, stderr, compression, outputSize := encodeLogOutput(step.Stdout, step.Stderr)
+		if _, err := tx.Exec(`INSERT OR REPLACE INTO job_log_steps(`+logStepColumns+`)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`,
+			entry.ID,
+			step.Index,
+			step.Name,
+			step.CommandLine,
+			parseRFC3339ToUnixMs(step.StartedAt),
+			parseRFC3339ToUnixMs(step.FinishedAt),
+			step.ExitCode,
+			step.Status,
+			step.ErrorCode,
+			step.DurationMs,
+			stdout,
+			stderr,
+			step.Error,
+			compression,
+			outputSize,
+		); err != nil {
+			return err
+		}
+	}
+	if err := indexLogRows(tx, `id = ?`, entry.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// markInterrupted finishes runs left running by a previous process. Their
+// real end is unknown, so they finish when they started.
+func (s *logStore) markInterrupted(now time.Time) error {
+	if err := s.ensureInit(); err != nil {
+		return err
+	}
+	reason := "wincron exited before the run finished, detected at " + now.Format(time.RFC3339)
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	if _, err := tx.Exec(interruptRunsSQL+`;`, reason); err != nil {
+		return err
+	}
+	if err := indexLogRows(tx, `status = 'interrupted' AND error = ?`, reason); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
+const interruptRunsSQL = `UPDATE job_logs SET
+	status = 'interrupted',
+	error_code = 'interrupted',
+	exit_code = -1,
+	finished_at = started_at,
+	error = ?
+	WHERE status = 'running'`
+
 func (s *logStore) clear() error {
 	if err := s.ensureInit(); err != nil {
 		return err
 	}
+	if _, err := s.db.Exec(`DELETE FROM job_log_steps;`); err != nil {
+		return err
+	}
 	_, err := s.db.Exec(`DELETE FROM job_logs;`)
 	return err
 }
@@ -99,6 +189,9 @@ func (s *logStore) clearJob(jobID string) error {
 	if s.clearJobStmt == nil {
 		return fmt.Errorf("log db not initialized")
 	}
+	if _, err := s.db.Exec(`DELETE FROM job_log_steps WHERE entry_id IN (SELECT id FROM job_logs WHERE job_id = ?);`, jobID); err != nil {
+		return err
+	}
 	_, err := s.clearJobStmt.Exec(jobID)
 	return err
 }
@@ -114,20 +207,87 @@ func (s *logStore) deleteEntry(entryID string) error {
 	if s.deleteEntryStmt == nil {
 		return fmt.Errorf("log db not initialized")
 	}
-	_, err := s.deleteEntryStmt.Exec(entryID)
+	if _, err := s.db.Exec(`DELETE FROM job_log_steps WHERE entry_id = ?;`, entryID); err != nil {
+		return err
+	}
+	_, err := s.deleteEntryStmt.Exec(entryID, entryID)
 	return err
 }
 
+type logFilter struct {
+	jobID         string
+	status        string
+	exitCode      *int
+	triggerSource string
+	fromMs        int64
+	toMs          int64
+	// match is an FTS5 query over the output columns.
+	match string
+}
+
+func (f logFilter) where() (string, []any) {
+	// Matrix children are listed under their parent, not on their own.
+	conds := []string{"parent_id = ''"}
+	var args []any
+	if jobID := strings.TrimSpace(f.jobID); jobID != "" {
+		conds = append(conds, "job_id = ?")
+		args = append(args, jobID)
+	}
+	if status := normalizeLogStatusFilter(f.status); status != "" {
+		conds = append(conds, "status = ?")
+		args = append(args, status)
+	}
+	if f.exitCode != nil {
+		conds = append(conds, "exit_code = ?")
+		args = append(args, *f.exitCode)
+	}
+	if f.triggerSource != "" {
+		conds = append(conds, "trigger_source = ?")
+		args = append(args, f.triggerSource)
+	}
+	if f.fromMs > 0 {
+		conds = append(conds, "started_at >= ?")
+		args = append(args, f.fromMs)
+	}
+	if f.toMs > 0 {
+		conds = append(conds, "started_at <= ?")
+		args = append(args, f.toMs)
+	}
+	if f.match != "" {
+		conds = append(conds, "search_id IN (SELECT rowid FROM job_logs_fts WHERE job_logs_fts MATCH ?)")
+		args = append(args, f.match)
+	}
+	return `
+		WHERE ` + strings.Join(conds, " AND "), args
+}
+
 func (s *logStore) tail(jobID string, limit int) ([]JobLogEntry, error) {
-	logs, _, _, err := s.page(jobID, 0, limit)
+	logs, _, _, err := s.page(logFilter{jobID: jobID}, 0, limit)
 	return logs, err
 }
 
-func (s *logStore) page(jobID string, offset int, limit int) ([]JobLogEntry, int, bool, error) {
+// lastFinished returns the latest run of jobID that ran to an end. Runs still
+// in progress, interrupted or never started have no complete output, and
+// skipped triggers none at all.
+func (s *logStore) lastFinished(jobID string) ([]JobLogEntry, error) {
+	if err := s.ensureInit(); err != nil {
+		return nil, err
+	}
+	rows, err := s.db.Query(`SELECT `+logEntryColumns+`
+		FROM job_logs
+		WHERE job_id = ? AND parent_id = '' AND status NOT IN ('running', 'interrupted', 'start_failed', 'skipped')
+		ORDER BY started_at DESC, id DESC
+		LIMIT 1;`, jobID)
+	if err != nil {
+		return nil, err
+	}
+	return scanLogEntries(rows)
+}
+
+func (s *logStore) page(filter logFilter, offset int, limit int) ([]JobLogEntry, int, bool, error) {
 	if err := s.ensureInit(); err != nil {
 		return nil, 0, false, err
 	}
-	jobID = strings.TrimSpace(jobID)
 	if offset < 0 {
 		offset = 0
 	}
@@ -135,7 +295,7 @@ func (s *logStore) page(jobID string, offset int, limit int) ([]JobLogEntry, int
 		limit = 100
 	}
 
-	totalCount, err := s.count(jobID)
+	totalCount, err := s.count(filter)
 	if err != nil {
 		return nil, 0, false, err
 	}
@@ -143,16 +303,10 @@ func (s *logStore) page(jobID string, offset int, limit int) ([]JobLogEntry, int
 		return []JobLogEntry{}, totalCount, false, nil
 	}
 
-	query := `SELECT id, job_id, job_name, trigger_source, command_line, started_at, finished_at, exit_code, stdout, stderr, error
-		FROM job_logs`
-	args := make([]any, 0, 3)
-	if jobID != "" {
-		query += `
-		WHERE job_id = ?`
-		args = append(args, jobID)
-	}
-	query += `
-		ORDER BY started_at DESC
+	where, args := filter.where()
+	query := `SELECT ` + logEntryColumns + `
+		FROM job_logs` + where + `
+		ORDER BY started_at DESC, id DESC
 		LIMIT ? OFFSET ?;`
 	args = append(args, limit, offset)
 
@@ -160,9 +314,132 @@ func (s *logStore) page(jobID string, offset int, limit int) ([]JobLogEntry, int
 	if err != nil {
 		return nil, 0, false, err
 	}
+	buf, err := scanLogEntries(rows)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	if buf == nil {
+		buf = []JobLogEntry{}
+	}
+
+	if err := s.loadSteps(buf); err != nil {
+		return nil, 0, false, err
+	}
+	if err := s.loadChildren(buf); err != nil {
+		return nil, 0, false, err
+	}
+
+	for i, j := 0, len(buf)-1; i < j; i, j = i+1, j-1 {
+		buf[i], buf[j] = buf[j], buf[i]
+	}
+	hasMore := offset+len(buf) < totalCount
+	return buf, totalCount, hasMore, nil
+}
+
+// pageAfter lists the runs older than cursor, newest first, then returns them
+// oldest first like page. Paging on (started_at, id) stays fast at any depth
+// and does not shift when new runs are stored. An empty cursor starts at the
+// newest run.
+func (s *logStore) pageAfter(filter logFilter, cursor string, limit int) ([]JobLogEntry, int, bool, string, error) {
+	if err := s.ensureInit(); err != nil {
+		return nil, 0, false, "", err
+	}
+	if limit <= 0 {
+		limit = 100
+	}
+
+	totalCount, err := s.count(filter)
+	if err != nil {
+		return nil, 0, false, "", err
+	}
+
+	where, args := filter.where()
+	if cursor != "" {
+		startedAtMs, id, err := parseLogCursor(cursor)
+		if err != nil {
+			return nil, 0, false, "", err
+		}
+		where += ` AND (started_at, id) < (?, ?)`
+		args = append(args, startedAtMs, id)
+	}
+	rows, err := s.db.Query(`SELECT `+logEntryColumns+`
+		FROM job_logs`+where+`
+		ORDER BY started_at DESC, id DESC
+		LIMIT ?;`, append(args, limit+1)...)
+	if err != nil {
+		return nil, 0, false, "", err
+	}
+	buf, err := scanLogEntries(rows)
+	if err != nil {
+		return nil, 0, false, "", err
+	}
+	if buf == nil {
+		buf = []JobLogEntry{}
+	}
+	hasMore := len(buf) > limit
+	if hasMore {
+		buf = buf[:limit]
+	}
+	next := ""
+	if hasMore {
+		last := buf[len(buf)-1]
+		next = formatLogCursor(parseRFC3339ToUnixMs(last.StartedAt), last.ID)
+	}
+
+	if err := s.loadSteps(buf); err != nil {
+		return nil, 0, false, "", err
+	}
+	if err := s.loadChildren(buf); err != nil {
+		return nil, 0, false, "", err
+	}
+	for i, j := 0, len(buf)-1; i < j; i, j = i+1, j-1 {
+		buf[i], buf[j] = buf[j], buf[i]
+	}
+	return buf, totalCount, hasMore, next, nil
+}
+
+func formatLogCursor(startedAtMs int64, id string) string {
+	return strconv.FormatInt(startedAtMs, 10) + ":" + id
+}
+
+func parseLogCursor(cursor string) (int64, string, error) {
+	ms, id, ok := strings.Cut(cursor, ":")
+	startedAtMs, err := strconv.ParseInt(ms, 10, 64)
+	if !ok || err != nil || id == "" {
+		return 0, "", fmt.Errorf("invalid cursor: %q", cursor)
+	}
+	return startedAtMs, id, nil
+}
+
+// upsertLogEntrySQL inserts a run or, for a run stored when it started,
+// replaces the running row in place.
+func upsertLogEntrySQL() string {
+	columns := strings.Split(logEntryColumns, ",")
+	sets := make([]string, 0, len(columns)-1)
+	for i, column := range columns {
+		column = strings.TrimSpace(column)
+		columns[i] = column
+		if column != "id" {
+			sets = append(sets, column+" = excluded."+column)
+		}
+	}
+	return `INSERT INTO job_logs(` + strings.Join(columns, ", ") + `)
+		VALUES (` + strings.TrimRight(strings.Repeat("?,", len(columns)), ",") + `)
+		ON CONFLICT(id) DO UPDATE SET ` + strings.Join(sets, ", ") + `;`
+}
+
+const logEntryColumns = `id, job_id, job_name, trigger_source, command_line, started_at, finished_at, exit_code, status, error_code, stop_stage,
+		duration_ms, user_cpu_ms, system_cpu_ms, peak_memory_bytes, stdout, stderr, stdout_spans, stderr_spans, overrides, error, parent_id, item,
+		scheduled_at, lateness_ms, output_compression, output_size`
+
+const logStepColumns = `entry_id, step_index, name, command_line, started_at, finished_at, exit_code, status, error_code,
+		duration_ms, stdout, stderr, error, output_compression, output_size`
+
+// scanLogEntries reads rows selected with logEntryColumns and closes them.
+func scanLogEntries(rows *sql.Rows) ([]JobLogEntry, error) {
 	defer rows.Close()
 
-	buf := make([]JobLogEntry, 0, limit)
+	var buf []JobLogEntry
 	for rows.Next() {
 		var (
 			id            string
@@ -173,9 +450,25 @@ func (s *logStore) page(jobID string, offset int, limit int) ([]JobLogEntry, int
 			startedAtMs   int64
 			finishedAtMs  int64
 			exitCode      int
-			stdout        string
-			stderr        string
+			status        string
+			errorCode     string
+			stopStage     string
+			durationMs    int64
+			userCPUMs     int64
+			systemCPUMs   int64
+			peakMemory    int64
+			stdout        []byte
+			stderr        []byte
+			stdoutSpans   string
+			stderrSpans   string
+			overrides     string
 			errText       string
+			parentID      string
+			item          string
+			scheduledAtMs int64
+			latenessMs    int64
+			compression   string
+			outputSize    int64
 		)
 		if err := rows.Scan(
 			&id,
@@ -186,93 +479,201 @@ func (s *logStore) page(jobID string, offset int, limit int) ([]JobLogEntry, int
 			&startedAtMs,
 			&finishedAtMs,
 			&exitCode,
+			&status,
+			&errorCode,
+			&stopStage,
+			&durationMs,
+			&userCPUMs,
+			&systemCPUMs,
+			&peakMemory,
 			&stdout,
 			&stderr,
+			&stdoutSpans,
+			&stderrSpans,
+			&overrides,
 			&errText,
+			&parentID,
+			&item,
+			&scheduledAtMs,
+			&latenessMs,
+			&compression,
+			&outputSize,
 		); err != nil {
-			return nil, 0, false, err
+			return nil, err
 		}
 		buf = append(buf, JobLogEntry{
-			ID:            id,
-			JobID:         jid,
-			JobName:       jobName,
-			TriggerSource: normalizeLogTriggerSource(triggerSource),
-			CommandLine:   commandLine,
-			StartedAt:     unixMsToRFC3339(startedAtMs),
-			FinishedAt:    unixMsToRFC3339(finishedAtMs),
-			ExitCode:      exitCode,
-			Stdout:        stdout,
-			Stderr:        stderr,
-			Error:         errText,
+			ID:              id,
+			JobID:           jid,
+			JobName:         jobName,
+			ParentID:        parentID,
+			Item:            item,
+			TriggerSource:   normalizeLogTriggerSource(triggerSource),
+			CommandLine:     commandLine,
+			StartedAt:       unixMsToRFC3339(startedAtMs),
+			ScheduledAt:     unixMsToRFC3339(scheduledAtMs),
+			LatenessMs:      latenessMs,
+			FinishedAt:      unixMsToRFC3339(finishedAtMs),
+			ExitCode:        exitCode,
+			Status:          normalizeLogStatus(status, exitCode),
+			ErrorCode:       errorCode,
+			StopStage:       stopStage,
+			DurationMs:      logDurationMs(durationMs, startedAtMs, finishedAtMs),
+			UserCPUMs:       userCPUMs,
+			SystemCPUMs:     systemCPUMs,
+			PeakMemoryBytes: peakMemory,
+			Stdout:          decodeLogOutput(stdout, compression),
+			Stderr:          decodeLogOutput(stderr, compression),
+			StdoutSpans:     decodeOutputSpans(stdoutSpans),
+			StderrSpans:     decodeOutputSpans(stderrSpans),
+			Overrides:       decodeRunOverrides(overrides),
+			Error:           errText,
 		})
 	}
 	if err := rows.Err(); err != nil {
-		return nil, 0, false, err
+		return nil, err
 	}
+	return buf, nil
+}
 
-	for i, j := 0, len(buf)-1; i < j; i, j = i+1, j-1 {
-		buf[i], buf[j] = buf[j], buf[i]
+// loadChildren fills the matrix child runs of entries.
+func (s *logStore) loadChildren(entries []JobLogEntry) error {
+	if len(entries) == 0 {
+		return nil
 	}
-	hasMore := offset+len(buf) < totalCount
-	return buf, totalCount, hasMore, nil
+	index := make(map[string]int, len(entries))
+	args := make([]any, 0, len(entries))
+	for i, entry := range entries {
+		index[entry.ID] = i
+		args = append(args, entry.ID)
+	}
+	placeholders := strings.TrimRight(strings.Repeat("?,", len(args)), ",")
+	rows, err := s.db.Query(`SELECT `+logEntryColumns+`
+		FROM job_logs
+		WHERE parent_id IN (`+placeholders+`)
+		ORDER BY rowid;`, args...)
+	if err != nil {
+		return err
+	}
+	children, err := scanLogEntries(rows)
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		if i, ok := index[child.ParentID]; ok {
+			entries[i].Children = append(entries[i].Children, child)
+		}
+	}
+	return nil
 }
 
-func (s *logStore) count(jobID string) (int, error) {
-	if err := s.ensureInit(); err != nil {
-		return 0, err
+// loadSteps fills the step results of multi-step runs in entries.
+func (s *logStore) loadSteps(entries []JobLogEntry) error {
+	if len(entries) == 0 {
+		return nil
 	}
-	jobID = strings.TrimSpace(jobID)
+	index := make(map[string]int, len(entries))
+	args := make([]any, 0, len(entries))
+	for i, entry := range entries {
+		index[entry.ID] = i
+		args = append(args, entry.ID)
+	}
+	placeholders := strings.TrimRight(strings.Repeat("?,", len(args)), ",")
+	rows, err := s.db.Query(`SELECT entry_id, step_index, name, command_line, started_at, finished_at, exit_code, status, error_code,
+		duration_ms, stdout, stderr, error, output_compression
+		FROM job_log_steps
+		WHERE entry_id IN (`+placeholders+`)
+		ORDER BY entry_id, step_index;`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			entryID        string
+			step           JobStepResult
+			startedAtMs    int64
+			finishedAtMs   int64
+			stdout, stderr []byte
+			compression    string
+		)
+		if err := rows.Scan(
+			&entryID,
+			&step.Index,
+			&step.Name,
+			&step.CommandLine,
+			&startedAtMs,
+			&finishedAtMs,
+			&step.ExitCode,
+			&step.Status,
+			&step.ErrorCode,
+			&step.DurationMs,
+			&stdout,
+			&stderr,
+			&step.Error,
+			&compression,
+		); err != nil {
+			return err
+		}
+		step.Stdout = decodeLogOutput(stdout, compression)
+		step.Stderr = decodeLogOutput(stderr, compression)
+		if startedAtMs > 0 {
+			step.StartedAt = unixMsToRFC3339(startedAtMs)
+		}
+		if finishedAtMs > 0 {
+			step.FinishedAt = unixMsToRFC3339(finishedAtMs)
+		}
+		if i, ok := index[entryID]; ok {
+			entries[i].Steps = append(entries[i].Steps, step)
+		}
+	}
+	return rows.Err()
+}
 
+// countsCached reports whether job_log_counts can answer count for filter.
+func (f logFilter) countsCached() bool {
+	return f.exitCode == nil && f.triggerSource == "" && f.fromMs == 0 && f.toMs == 0 && f.match == ""
+}
+
+func (s *logStore) cachedCount(filter logFilter) (int, error) {
 	var (
-		count int
-		err   error
+		conds []string
+		args  []any
 	)
-	if jobID == "" {
-		err = s.db.QueryRow(`SELECT COUNT(1) FROM job_logs;`).Scan(&count)
-	} else {
-		err = s.db.QueryRow(`SELECT COUNT(1) FROM job_logs WHERE job_id = ?;`, jobID).Scan(&count)
+	if jobID := strings.TrimSpace(filter.jobID); jobID != "" {
+		conds = append(conds, "job_id = ?")
+		args = append(args, jobID)
 	}
+	if status := normalizeLogStatusFilter(filter.status); status != "" {
+		conds = append(conds, "status = ?")
+		args = append(args, status)
+	}
+	query := `SELECT COALESCE(SUM(count), 0) FROM job_log_counts`
+	if len(conds) > 0 {
+		query += ` WHERE ` + strings.Join(conds, " AND ")
+	}
+	var count int
+	err := s.db.QueryRow(query+`;`, args...).Scan(&count)
 	return count, err
 }
 
-func (s *logStore) countExistingIDs(jobID string, ids []string) (int, error) {
+func (s *logStore) count(filter logFilter) (int, error) {
 	if err := s.ensureInit(); err != nil {
 		return 0, err
 	}
-	jobID = strings.TrimSpace(jobID)
-
-	seen := make(map[string]struct{}, len(ids))
-	args := make([]any, 0, len(ids)+1)
-	for _, id := range ids {
-		id = strings.TrimSpace(id)
-		if id == "" {
-			continue
-		}
-		if _, ok := seen[id]; ok {
-			continue
-		}
-		seen[id] = struct{}{}
-		args = append(args, id)
-	}
-	if len(args) == 0 {
-		return 0, nil
+	if filter.countsCached() {
+		return s.cachedCount(filter)
 	}
 
-	placeholders := strings.TrimRight(strings.Repeat("?,", len(args)), ",")
-	query := `SELECT COUNT(1) FROM job_logs WHERE id IN (` + placeholders + `)`
-	if jobID != "" {
-		query += ` AND job_id = ?`
-		args = append(args, jobID)
-	}
-	query += `;`
-
+	where, args := filter.where()
 	var count int
-	if err := s.db.QueryRow(query, args...).Scan(&count); err != nil {
-		return 0, err
-	}
-	return count, nil
+	err := s.db.QueryRow(`SELECT COUNT(1) FROM job_logs`+where+`;`, args...).Scan(&count)
+	return count, err
 }
 
+// merge copies the runs of another logs.sqlite that are not here yet. The
+// other database is copied and migrated to the current schema first, so it may
+// come from any older wincron and is itself left untouched.
 func (s *logStore) merge(otherPath string) error {
 	if err := s.ensureInit(); err != nil {
 		return err
@@ -282,43 +683,122 @@ func (s *logStore) merge(otherPath string) error {
 		return fmt.Errorf("otherPath is required")
 	}
 
-	tx, err := s.db.Begin()
+	upgradedPath, err := s.upgradedLogCopy(otherPath)
 	if err != nil {
 		return err
 	}
-	defer func() {
-		_ = tx.Rollback()
-	}()
-
-	attachSQL := "ATTACH DATABASE " + quoteSQLiteString(otherPath) + " AS other;"
-	if _, err := tx.Exec(attachSQL); err != nil {
+	// The copy can only be removed once it is detached; Windows keeps an
+	// attached file locked.
+	if err := s.mergeAttached(upgradedPath); err != nil {
+		_ = os.Remove(upgradedPath)
 		return err
 	}
-	defer func() {
-		_, _ = tx.Exec("DETACH DATABASE other;")
-	}()
+	return os.Remove(upgradedPath)
+}
 
-	otherHasTriggerSource, err := hasSQLiteColumn(tx, "other", "job_logs", "trigger_source")
+// mergeAttached runs the merge on one connection, since ATTACH and DETACH
+// apply to a connection rather than the pool, and detaches only after the
+// transaction has ended.
+func (s *logStore) mergeAttached(upgradedPath string) error {
+	ctx := context.Background()
+	conn, err := s.db.Conn(ctx)
 	if err != nil {
 		return err
 	}
-	selectTriggerSource := "''"
-	if otherHasTriggerSource {
-		selectTriggerSource = "trigger_source"
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "ATTACH DATABASE "+quoteSQLiteString(upgradedPath)+" AS other;"); err != nil {
+		return err
+	}
+	err = mergeFromOther(ctx, conn)
+	if _, detachErr := conn.ExecContext(ctx, "DETACH DATABASE other;"); detachErr != nil && err == nil {
+		err = detachErr
 	}
+	return err
+}
 
-	_, err = tx.Exec(`INSERT OR IGNORE INTO job_logs(
-		id, job_id, job_name, trigger_source, command_line, started_at, finished_at, exit_code, stdout, stderr, error
-	) SELECT
-		id, job_id, job_name, ` + selectTriggerSource + `, command_line, started_at, finished_at, exit_code, stdout, stderr, error
-	  FROM other.job_logs;`)
+func mergeFromOther(ctx context.Context, conn *sql.Conn) error {
+	tx, err := conn.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// Rows this merge adds are the ones numbered past the current maximum.
+	var lastSearchID int64
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(search_id), 0) FROM job_logs;`).Scan(&lastSearchID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT OR IGNORE INTO job_logs(` + logEntryColumns + `)
+	  SELECT ` + logEntryColumns + `
+	  FROM other.job_logs;`); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT OR IGNORE INTO job_log_steps(` + logStepColumns + `)
+	  SELECT ` + logStepColumns + `
+	  FROM other.job_log_steps;`); err != nil {
+		return err
+	}
+	// Runs that were in progress in the other database will never finish here.
+	if _, err := tx.Exec(interruptRunsSQL+` AND search_id > ?;`, "merged while still running", lastSearchID); err != nil {
+		return err
+	}
+	if err := indexLogRows(tx, `search_id > ?`, lastSearchID); err != nil {
+		return err
+	}
 
 	return tx.Commit()
 }
 
+// upgradedLogCopy writes a consistent copy of the database at path, including
+// anything still in its WAL, to a temporary file and migrates the copy.
+func (s *logStore) upgradedLogCopy(path string) (string, error) {
+	if _, err := os.Stat(path); err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp("", "wincron-merge-*.sqlite")
+	if err != nil {
+		return "", err
+	}
+	copyPath := f.Name()
+	_ = f.Close()
+	// VACUUM INTO wants a file that does not exist yet.
+	_ = os.Remove(copyPath)
+
+	ctx := context.Background()
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return "", err
+	}
+	if _, err := conn.ExecContext(ctx, "ATTACH DATABASE "+quoteSQLiteString(path)+" AS merge_source;"); err != nil {
+		_ = conn.Close()
+		return "", err
+	}
+	_, err = conn.ExecContext(ctx, "VACUUM merge_source INTO "+quoteSQLiteString(copyPath)+";")
+	if _, detachErr := conn.ExecContext(ctx, "DETACH DATABASE merge_source;"); detachErr != nil && err == nil {
+		err = detachErr
+	}
+	_ = conn.Close()
+	if err != nil {
+		_ = os.Remove(copyPath)
+		return "", err
+	}
+
+	db, err := sql.Open("sqlite", copyPath)
+	if err == nil {
+		db.SetMaxOpenConns(1)
+		err = migrateLogDB(db)
+		_ = db.Close()
+	}
+	if err != nil {
+		_ = os.Remove(copyPath)
+		return "", fmt.Errorf("merge %s: %w", path, err)
+	}
+	return copyPath, nil
+}
+
 func (s *logStore) ensureInit() error {
 	if s == nil {
 		return fmt.Errorf("log store is nil")
@@ -361,41 +841,12 @@ func (s *logStore) init() error {
 		return err
 	}
 
-	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS job_logs (
-		id TEXT PRIMARY KEY,
-		job_id TEXT NOT NULL,
-		job_name TEXT NOT NULL,
-		trigger_source TEXT NOT NULL DEFAULT '',
-		command_line TEXT NOT NULL,
-		started_at INTEGER NOT NULL,
-		finished_at INTEGER NOT NULL,
-		exit_code INTEGER NOT NULL,
-		stdout TEXT NOT NULL,
-		stderr TEXT NOT NULL,
-		error TEXT NOT NULL
-	);`); err != nil {
-		_ = db.Close()
-		return err
-	}
-	hasTriggerSource, err := hasSQLiteColumn(db, "", "job_logs", "trigger_source")
-	if err != nil {
-		_ = db.Close()
-		return err
-	}
-	if !hasTriggerSource {
-		if _, err := db.Exec(`ALTER TABLE job_logs ADD COLUMN trigger_source TEXT NOT NULL DEFAULT '';`); err != nil {
-			_ = db.Close()
-			return err
-		}
-	}
-	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_job_logs_job_id_started_at ON job_logs(job_id, started_at DESC);`); err != nil {
+	if err := migrateLogDB(db); err != nil {
 		_ = db.Close()
 		return err
 	}
 
-	insertStmt, err := db.Prepare(`INSERT INTO job_logs(
-		id, job_id, job_name, trigger_source, command_line, started_at, finished_at, exit_code, stdout, stderr, error
-	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`)
+	insertStmt, err := db.Prepare(upsertLogEntrySQL())
 	if err != nil {
 		_ = db.Close()
 		return err
@@ -408,7 +859,7 @@ func (s *logStore) init() error {
 		return err
 	}
 
-	deleteEntryStmt, err := db.Prepare(`DELETE FROM job_logs WHERE id = ?;`)
+	deleteEntryStmt, err := db.Prepare(`DELETE FROM job_logs WHERE id = ? OR parent_id = ?;`)
 	if err != nil {
 		_ = clearJobStmt.Close()
 		_ = insertStmt.Close()
@@ -423,6 +874,31 @@ func (s *logStore) init() error {
 	return nil
 }
 
+// backfillLogStatusSQL classifies rows written before the status column
+// existed, using the only signals those rows carry.
+const backfillLogStatusSQL = `UPDATE job_logs SET
+	status = CASE
+		WHEN error LIKE 'timeout after %' THEN 'timeout'
+		WHEN exit_code = 0 THEN 'success'
+		ELSE 'failed'
+	END,
+	error_code = CASE
+		WHEN error LIKE 'timeout after %' THEN 'timeout'
+		WHEN exit_code = 0 THEN ''
+		WHEN exit_code = -1 THEN 'start_error'
+		ELSE 'exit_code'
+	END
+	WHERE status = '';`
+
+func addSQLiteColumnIfMissing(db sqlConn, table string, column string, definition string) error {
+	exists, err := hasSQLiteColumn(db, "", table, column)
+	if err != nil || exists {
+		return err
+	}
+	_, err = db.Exec(`ALTER TABLE ` + table + ` ADD COLUMN ` + column + ` ` + definition + `;`)
+	return err
+}
+
 func hasSQLiteColumn(q interface {
 	Query(string, ...any) (*sql.Rows, error)
 }, schema string, table string, column string) (bool, error) {
@@ -465,6 +941,15 @@ func hasSQLiteColumn(q interface {
 	return false, nil
 }
 
+// logDurationMs falls back to the second-resolution timestamps for rows
+// written before duration_ms existed.
+func logDurationMs(durationMs int64, startedAtMs int64, finishedAtMs int64) int64 {
+	if durationMs > 0 || startedAtMs <= 0 || finishedAtMs < startedAtMs {
+		return durationMs
+	}
+	return finishedAtMs - startedAtMs
+}
+
 func parseRFC3339ToUnixMs(raw string) int64 {
 	raw = strings.TrimSpace(raw)
 	if raw == "" {
@@ -489,3 +974,25 @@ func unixMsToRFC3339(ms int64) string {
 func quoteSQLiteString(v string) string {
 	return "'" + strings.ReplaceAll(v, "'", "''") + "'"
 }
+
+func encodeRunOverrides(overrides *RunOverrides) string {
+	if overrides == nil {
+		return ""
+	}
+	data, err := json.Marshal(overrides)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func decodeRunOverrides(value string) *RunOverrides {
+	if value == "" {
+		return nil
+	}
+	var overrides RunOverrides
+	if err := json.Unmarshal([]byte(value), &overrides); err != nil {
+		return nil
+	}
+	return &overrides
+}
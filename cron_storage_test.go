@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestLogStore(t *testing.T) *logStore {
+	t.Helper()
+	s := newLogStore(filepath.Join(t.TempDir(), "logs.db"))
+	if s.initErr != nil {
+		t.Fatalf("init log store: %v", s.initErr)
+	}
+	t.Cleanup(func() { _ = s.db.Close() })
+	return s
+}
+
+func testLogEntry(id string, jobID string, started time.Time, status string) JobLogEntry {
+	entry := JobLogEntry{
+		ID:          id,
+		JobID:       jobID,
+		JobName:     "job " + jobID,
+		CommandLine: "cmd /c echo " + id,
+		StartedAt:   started.UTC().Format(time.RFC3339),
+		Status:      status,
+	}
+	if status != logStatusRunning {
+		entry.FinishedAt = started.Add(time.Second).UTC().Format(time.RFC3339)
+		entry.DurationMs = 1000
+		if status != logStatusSuccess {
+			entry.ExitCode = 1
+		}
+	}
+	return entry
+}
+
+func TestMarkInterruptedFinishesRunningRows(t *testing.T) {
+	s := newTestLogStore(t)
+	start := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	if err := s.append(testLogEntry("done", "a", start, logStatusSuccess)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.append(testLogEntry("left", "a", start.Add(time.Hour), logStatusRunning)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.markInterrupted(start.Add(2 * time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	logs, err := s.tail("a", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// tail lists the oldest run first.
+	if len(logs) != 2 || logs[1].ID != "left" {
+		t.Fatalf("tail = %+v", logs)
+	}
+	if got := logs[1]; got.Status != logStatusInterrupted || got.ErrorCode != logErrorInterrupted || got.FinishedAt != got.StartedAt {
+		t.Errorf("interrupted row = status %q, error code %q, finished %q", got.Status, got.ErrorCode, got.FinishedAt)
+	}
+	if logs[0].Status != logStatusSuccess {
+		t.Errorf("finished row became %q", logs[0].Status)
+	}
+
+	last, err := s.lastFinished("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(last) != 1 || last[0].ID != "done" {
+		t.Errorf("lastFinished = %+v, want the successful run", last)
+	}
+}
+
+func TestPageAfterWalksAllRunsOnce(t *testing.T) {
+	s := newTestLogStore(t)
+	start := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	var want []string
+	for i := 0; i < 7; i++ {
+		// Pairs of runs share a start second, so the id breaks the tie.
+		id := fmt.Sprintf("r%d", i)
+		if err := s.append(testLogEntry(id, "a", start.Add(time.Duration(i/2)*time.Minute), logStatusSuccess)); err != nil {
+			t.Fatal(err)
+		}
+		want = append([]string{id}, want...)
+	}
+
+	var got []string
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 5 {
+			t.Fatal("paging does not end")
+		}
+		logs, total, hasMore, next, err := s.pageAfter(logFilter{jobID: "a"}, cursor, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wantTotal := min(pages, 1) + 7; total != wantTotal {
+			t.Errorf("page %d: total = %d, want %d", pages, total, wantTotal)
+		}
+		// Each page lists its runs oldest first.
+		for i := len(logs) - 1; i >= 0; i-- {
+			got = append(got, logs[i].ID)
+		}
+		if pages == 0 {
+			// A run arriving while paging must not shift later pages.
+			if err := s.append(testLogEntry("new", "a", start.Add(time.Hour), logStatusSuccess)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if !hasMore {
+			if next != "" {
+				t.Errorf("last page returned cursor %q", next)
+			}
+			break
+		}
+		cursor = next
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("paged %v, want %v", got, want)
+	}
+
+	if _, _, _, _, err := s.pageAfter(logFilter{}, "garbage", 3); err == nil {
+		t.Error("accepted an invalid cursor")
+	}
+}
+
+func TestCachedCountsFollowChanges(t *testing.T) {
+	s := newTestLogStore(t)
+	start := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	check := func(step string) {
+		t.Helper()
+		for _, f := range []logFilter{{}, {jobID: "a"}, {jobID: "b"}, {status: logStatusSuccess}, {jobID: "a", status: logStatusFailed}} {
+			if !f.countsCached() {
+				t.Fatalf("%+v is not served from job_log_counts", f)
+			}
+			cached, err := s.count(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			where, args := f.where()
+			var counted int
+			if err := s.db.QueryRow(`SELECT COUNT(1) FROM job_logs`+where+`;`, args...).Scan(&counted); err != nil {
+				t.Fatal(err)
+			}
+			if cached != counted {
+				t.Errorf("%s: count(%+v) = %d, rows = %d", step, f, cached, counted)
+			}
+		}
+	}
+
+	running := testLogEntry("r1", "a", start, logStatusRunning)
+	for _, e := range []JobLogEntry{
+		running,
+		testLogEntry("r2", "a", start.Add(time.Minute), logStatusFailed),
+		testLogEntry("r3", "b", start.Add(2*time.Minute), logStatusSuccess),
+	} {
+		if err := s.append(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	check("insert")
+
+	finished := testLogEntry("r1", "a", start, logStatusSuccess)
+	if err := s.append(finished); err != nil {
+		t.Fatal(err)
+	}
+	check("finish running row")
+
+	if err := s.deleteEntry("r2"); err != nil {
+		t.Fatal(err)
+	}
+	check("delete")
+
+	if err := s.clearJob("b"); err != nil {
+		t.Fatal(err)
+	}
+	check("clear job")
+}
+
+func TestMergeInterruptsOnlyMergedRuns(t *testing.T) {
+	start := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	otherPath := filepath.Join(t.TempDir(), "other.db")
+	other := newLogStore(otherPath)
+	if other.initErr != nil {
+		t.Fatal(other.initErr)
+	}
+	orphan := testLogEntry("orphan", "a", start, logStatusRunning)
+	orphan.Steps = []JobStepResult{{Index: 0, Name: "copy", Status: logStatusRunning, Stdout: "copying"}}
+	for _, entry := range []JobLogEntry{testLogEntry("live", "a", start, logStatusRunning), orphan} {
+		if err := other.append(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := other.db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s := newTestLogStore(t)
+	if err := s.append(testLogEntry("live", "a", start, logStatusRunning)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.merge(otherPath); err != nil {
+		t.Fatal(err)
+	}
+
+	for id, want := range map[string]string{"live": logStatusRunning, "orphan": logStatusInterrupted} {
+		var status string
+		if err := s.db.QueryRow(`SELECT status FROM job_logs WHERE id = ?;`, id).Scan(&status); err != nil {
+			t.Fatal(err)
+		}
+		if status != want {
+			t.Errorf("%s is %s after the merge, want %s", id, status, want)
+		}
+	}
+	var steps int
+	if err := s.db.QueryRow(`SELECT COUNT(1) FROM job_log_steps WHERE entry_id = 'orphan' AND stdout = 'copying';`).Scan(&steps); err != nil {
+		t.Fatal(err)
+	}
+	if steps != 1 {
+		t.Errorf("merged %d steps, want 1", steps)
+	}
+}
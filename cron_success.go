@@ -0,0 +1,132 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type exitCodeRange struct {
+	min int
+	max int
+}
+
+// parseSuccessExitCodes parses a comma separated list of exit codes and
+// inclusive ranges, e.g. "0,2-7". An empty spec means only 0 is a success.
+func parseSuccessExitCodes(spec string) ([]exitCodeRange, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return []exitCodeRange{{0, 0}}, nil
+	}
+
+	var ranges []exitCodeRange
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		lo, hi, isRange := strings.Cut(part, "-")
+		min, err := strconv.Atoi(strings.TrimSpace(lo))
+		if err != nil {
+			return nil, fmt.Errorf("invalid exit code: %s", part)
+		}
+		max := min
+		if isRange {
+			if max, err = strconv.Atoi(strings.TrimSpace(hi)); err != nil {
+				return nil, fmt.Errorf("invalid exit code range: %s", part)
+			}
+		}
+		if min < 0 || max < min {
+			return nil, fmt.Errorf("invalid exit code range: %s", part)
+		}
+		ranges = append(ranges, exitCodeRange{min, max})
+	}
+	if len(ranges) == 0 {
+		return nil, errors.New("successExitCodes is empty")
+	}
+	return ranges, nil
+}
+
+func normalizeSuccessExitCodes(spec string) (string, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return "", nil
+	}
+	ranges, err := parseSuccessExitCodes(spec)
+	if err != nil {
+		return "", err
+	}
+	parts := make([]string, 0, len(ranges))
+	for _, r := range ranges {
+		if r.min == r.max {
+			parts = append(parts, strconv.Itoa(r.min))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", r.min, r.max))
+		}
+	}
+	return strings.Join(parts, ","), nil
+}
+
+func validateOutputPattern(field string, pattern string) error {
+	if strings.TrimSpace(pattern) == "" {
+		return nil
+	}
+	if _, err := regexp.Compile(pattern); err != nil {
+		return fmt.Errorf("invalid %s: %w", field, err)
+	}
+	return nil
+}
+
+func validateSuccessCriteria(job *Job) error {
+	codes, err := normalizeSuccessExitCodes(job.SuccessExitCodes)
+	if err != nil {
+		return fmt.Errorf("invalid successExitCodes: %w", err)
+	}
+	job.SuccessExitCodes = codes
+	if err := validateOutputPattern("failIfOutputMatches", job.FailIfOutputMatches); err != nil {
+		return err
+	}
+	return validateOutputPattern("succeedIfOutputMatches", job.SucceedIfOutputMatches)
+}
+
+// outputMatches reports whether pattern matches any of outputs. Each stream
+// is matched on its own, so a match never spans stdout and stderr.
+func outputMatches(pattern string, outputs []string) bool {
+	if strings.TrimSpace(pattern) == "" {
+		return false
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return false
+	}
+	for _, output := range outputs {
+		if re.MatchString(output) {
+			return true
+		}
+	}
+	return false
+}
+
+// evaluateExitStatus decides the status and error code of a run whose process
+// exited, given its complete decoded output streams. failIfOutputMatches wins
+// over everything, succeedIfOutputMatches over the exit code.
+func evaluateExitStatus(job Job, exitCode int, outputs ...string) (string, string) {
+	if outputMatches(job.FailIfOutputMatches, outputs) {
+		return logStatusFailed, logErrorOutputMatched
+	}
+	if outputMatches(job.SucceedIfOutputMatches, outputs) {
+		return logStatusSuccess, ""
+	}
+	ranges, err := parseSuccessExitCodes(job.SuccessExitCodes)
+	if err != nil {
+		ranges = []exitCodeRange{{0, 0}}
+	}
+	for _, r := range ranges {
+		if exitCode >= r.min && exitCode <= r.max {
+			return logStatusSuccess, ""
+		}
+	}
+	return logStatusFailed, logErrorExitCode
+}
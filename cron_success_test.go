@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEvaluateExitStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		job        Job
+		exitCode   int
+		outputs    []string
+		wantStatus string
+		wantCode   string
+	}{
+		{"zero exits succeed", Job{}, 0, nil, logStatusSuccess, ""},
+		{"non-zero exits fail", Job{}, 1, nil, logStatusFailed, logErrorExitCode},
+		{"listed code", Job{SuccessExitCodes: "0,3"}, 3, nil, logStatusSuccess, ""},
+		{"inside range", Job{SuccessExitCodes: "0,2-7"}, 5, nil, logStatusSuccess, ""},
+		{"outside range", Job{SuccessExitCodes: "2-7"}, 0, nil, logStatusFailed, logErrorExitCode},
+		{"invalid spec falls back to zero", Job{SuccessExitCodes: "x"}, 0, nil, logStatusSuccess, ""},
+		{"fail pattern beats exit code", Job{FailIfOutputMatches: `(?i)error`}, 0, []string{"ERROR: disk full"}, logStatusFailed, logErrorOutputMatched},
+		{"succeed pattern beats exit code", Job{SucceedIfOutputMatches: `^done$`}, 1, []string{"done"}, logStatusSuccess, ""},
+		{"fail pattern beats succeed pattern", Job{FailIfOutputMatches: "warn", SucceedIfOutputMatches: "done"}, 0, []string{"done with warn"}, logStatusFailed, logErrorOutputMatched},
+		{"pattern sees decoded text", Job{SucceedIfOutputMatches: "完成"}, 1, []string{"任务完成"}, logStatusSuccess, ""},
+		{"invalid pattern never matches", Job{FailIfOutputMatches: "("}, 0, []string{"("}, logStatusSuccess, ""},
+		{"pattern checks stderr too", Job{FailIfOutputMatches: "denied"}, 0, []string{"ok", "access denied"}, logStatusFailed, logErrorOutputMatched},
+		{"match never spans streams", Job{FailIfOutputMatches: "out err"}, 0, []string{"out", " err"}, logStatusSuccess, ""},
+		{"anchors apply per stream", Job{SucceedIfOutputMatches: `^done$`}, 1, []string{"done", "warning"}, logStatusSuccess, ""},
+		{"match past the stored length", Job{FailIfOutputMatches: "FATAL"}, 0, []string{strings.Repeat("x", 20*1024) + "FATAL"}, logStatusFailed, logErrorOutputMatched},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, code := evaluateExitStatus(tt.job, tt.exitCode, tt.outputs...)
+			if status != tt.wantStatus || code != tt.wantCode {
+				t.Errorf("evaluateExitStatus = %q, %q; want %q, %q", status, code, tt.wantStatus, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestNormalizeSuccessExitCodes(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    string
+		wantErr bool
+	}{
+		{"", "", false},
+		{" 0 , 2 - 7 ", "0,2-7", false},
+		{"1,1-1", "1,1", false},
+		{"7-2", "", true},
+		{"-1", "", true},
+		{"a", "", true},
+		{",", "", true},
+	}
+	for _, tt := range tests {
+		got, err := normalizeSuccessExitCodes(tt.spec)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("normalizeSuccessExitCodes(%q) = %q, %v; want %q, err %v", tt.spec, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
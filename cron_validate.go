@@ -0,0 +1,273 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// JobIssue is a single finding of ValidateJob. Code is stable for the UI;
+// Message is meant for people.
+type JobIssue struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type JobValidation struct {
+	Valid    bool       `json:"valid"`
+	Errors   []JobIssue `json:"errors"`
+	Warnings []JobIssue `json:"warnings"`
+}
+
+func (v *JobValidation) addError(field, code, format string, args ...any) {
+	v.Errors = append(v.Errors, JobIssue{Field: field, Code: code, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *JobValidation) addWarning(field, code, format string, args ...any) {
+	v.Warnings = append(v.Warnings, JobIssue{Field: field, Code: code, Message: fmt.Sprintf(format, args...)})
+}
+
+// ValidateJob checks a job definition without saving it. Errors are problems
+// with the definition itself and block saving; warnings are likely mistakes,
+// including programs and directories missing on this machine, which only make
+// runs fail here.
+func (s *CronService) ValidateJob(job Job) (JobValidation, error) {
+	v := JobValidation{Errors: []JobIssue{}, Warnings: []JobIssue{}}
+
+	job.Shell = normalizeJobShell(job.Shell)
+	if job.Shell != "" {
+		if strings.TrimSpace(job.Script) == "" {
+			v.addError("script", "required", "script is required")
+		}
+	} else if strings.TrimSpace(job.Command) == "" && len(job.Steps) == 0 {
+		v.addError("command", "required", "command is required")
+	}
+	if _, err := normalizeJobSteps(job.Steps); err != nil {
+		v.addError("steps", "required", "%v", err)
+	}
+	if matrix, err := normalizeJobMatrix(job.Matrix); err != nil {
+		v.addError("matrix", "required", "%v", err)
+	} else if matrix != nil {
+		if len(job.Steps) > 0 {
+			v.addError("matrix", "matrix_with_steps", "matrix cannot be combined with steps")
+		}
+		if _, err := matrixItems(matrix); err != nil {
+			v.addError("matrix", logErrorMatrixUnavailable, "matrix: %v", err)
+		}
+	}
+
+	expr := strings.TrimSpace(job.Cron)
+	var schedule cron.Schedule
+	if expr != "" && !isRebootCron(expr) {
+		parsed, err := s.parser.Parse(expr)
+		if err != nil {
+			v.addError("cron", "invalid_cron", "invalid cron: %v", err)
+		} else {
+			schedule = parsed
+		}
+	}
+
+	if _, err := normalizeSuccessExitCodes(job.SuccessExitCodes); err != nil {
+		v.addError("successExitCodes", "invalid_exit_codes", "invalid successExitCodes: %v", err)
+	}
+	if err := validateOutputPattern("failIfOutputMatches", job.FailIfOutputMatches); err != nil {
+		v.addError("failIfOutputMatches", "invalid_pattern", "%v", err)
+	}
+	if err := validateOutputPattern("succeedIfOutputMatches", job.SucceedIfOutputMatches); err != nil {
+		v.addError("succeedIfOutputMatches", "invalid_pattern", "%v", err)
+	}
+	if _, ok := normalizeOutputEncoding(job.OutputEncoding); !ok {
+		v.addError("outputEncoding", "unsupported_encoding", "unsupported outputEncoding: %s", job.OutputEncoding)
+	}
+
+	params, err := normalizeJobParams(job.Params)
+	if err != nil {
+		v.addError("params", logErrorInvalidParams, "%v", err)
+	}
+	defaults, err := resolveJobParams(params, nil)
+	if err != nil {
+		v.addWarning("params", logErrorInvalidParams, "cron and hotkey runs will fail: %v", err)
+		defaults = make(map[string]string, len(params))
+		for _, p := range params {
+			defaults[p.Name] = p.Default
+		}
+	}
+
+	if strings.TrimSpace(job.Hotkey) != "" {
+		s.mu.Lock()
+		_, err := s.normalizeJobHotkeyLocked(job.ID, job.Hotkey)
+		s.mu.Unlock()
+		if err != nil {
+			v.addError("hotkey", "hotkey_conflict", "%v", err)
+		}
+	}
+
+	now := time.Now()
+	runCtx := newRunContext(job, newRunningLogEntry(job, logTriggerSourceUI, now), runOptions{
+		triggerSource: logTriggerSourceUI,
+		scheduledAt:   now,
+	}, now)
+	runCtx.Params = defaults
+	if _, err := runCtx.renderAll(job.Args); err != nil {
+		v.addError("args", logErrorInvalidTemplate, "render args: %v", err)
+	}
+	workDir, err := runCtx.render(job.WorkDir)
+	if err != nil {
+		v.addError("workDir", logErrorInvalidTemplate, "render workDir: %v", err)
+	} else {
+		validateJobWorkDir(&v, workDir)
+		if len(job.Steps) == 0 {
+			validateJobExecutable(&v, job, workDir)
+		}
+	}
+	for i, step := range job.Steps {
+		sj := stepJob(job, step, i)
+		if _, err := runCtx.renderAll(sj.Args); err != nil {
+			v.addError(fmt.Sprintf("steps[%d].args", i), logErrorInvalidTemplate, "render args: %v", err)
+		}
+		stepDir, err := runCtx.render(sj.WorkDir)
+		if err != nil {
+			v.addError(fmt.Sprintf("steps[%d].workDir", i), logErrorInvalidTemplate, "render workDir: %v", err)
+			continue
+		}
+		if sj.WorkDir != job.WorkDir {
+			validateJobWorkDir(&v, stepDir)
+		}
+		validateJobExecutable(&v, sj, stepDir)
+	}
+
+	validateJobStdin(&v, s, job)
+	validateJobTimeout(&v, job, schedule, now)
+
+	if expr == "" && strings.TrimSpace(job.Hotkey) == "" {
+		v.addWarning("cron", "never_scheduled", "job has no cron and no hotkey; it only runs manually")
+	}
+
+	v.Valid = len(v.Errors) == 0
+	return v, nil
+}
+
+func validateJobWorkDir(v *JobValidation, workDir string) {
+	if strings.TrimSpace(workDir) == "" {
+		return
+	}
+	info, err := os.Stat(workDir)
+	if err != nil {
+		v.addWarning("workDir", logErrorWorkDirNotFound, "workDir not found: %s", workDir)
+	} else if !info.IsDir() {
+		v.addWarning("workDir", logErrorWorkDirNotFound, "workDir is not a directory: %s", workDir)
+	}
+}
+
+// validateJobExecutable resolves the program the way exec.Command does: bare
+// names through PATH, relative paths against the working directory.
+func validateJobExecutable(v *JobValidation, job Job, workDir string) {
+	command := strings.TrimSpace(job.Command)
+	field := "command"
+	if isShellJob(job) && command == "" {
+		command = jobShellSpecs[job.Shell].exe
+		field = "shell"
+	}
+	if command == "" || strings.Contains(command, "{{") {
+		return
+	}
+
+	if filepath.Base(command) == command {
+		if _, err := exec.LookPath(command); err != nil {
+			v.addWarning(field, logErrorExecutableNotFound, "executable not found in PATH: %s", command)
+		}
+		return
+	}
+	path := command
+	if !filepath.IsAbs(path) {
+		dir, err := resolveJobWorkDir(workDir)
+		if err != nil {
+			return
+		}
+		path = filepath.Join(dir, path)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		if _, lookErr := exec.LookPath(path); lookErr == nil {
+			return
+		}
+		v.addWarning(field, logErrorExecutableNotFound, "executable not found: %s", path)
+	} else if info.IsDir() {
+		v.addWarning(field, logErrorExecutableNotFound, "executable is a directory: %s", path)
+	}
+}
+
+func validateJobStdin(v *JobValidation, s *CronService, job Job) {
+	switch normalizeStdinSource(job.StdinSource) {
+	case stdinSourceFile:
+		path := strings.TrimSpace(job.Stdin)
+		if path == "" {
+			v.addError("stdin", "required", "stdin file path is required")
+		} else if _, err := os.Stat(path); err != nil {
+			v.addWarning("stdin", logErrorStdinUnavailable, "stdin file not found: %s", path)
+		}
+	case stdinSourceJob:
+		if _, err := s.previousJobOutput(job.Stdin); err != nil {
+			v.addWarning("stdin", logErrorStdinUnavailable, "%v", err)
+		}
+	}
+}
+
+// validateJobTimeout warns when a run may still be going when the next one is
+// due, using the shortest gap among the next few scheduled runs.
+func validateJobTimeout(v *JobValidation, job Job, schedule cron.Schedule, now time.Time) {
+	if job.Timeout <= 0 || schedule == nil {
+		return
+	}
+	var interval time.Duration
+	prev := schedule.Next(now)
+	for i := 0; i < 5 && !prev.IsZero(); i++ {
+		next := schedule.Next(prev)
+		if next.IsZero() {
+			break
+		}
+		if gap := next.Sub(prev); interval == 0 || gap < interval {
+			interval = gap
+		}
+		prev = next
+	}
+	timeout := time.Duration(job.Timeout) * time.Second
+	if interval > 0 && timeout > interval {
+		v.addWarning("timeout", "timeout_exceeds_interval", "timeout %s is longer than the schedule interval %s", timeout, interval)
+	}
+}
+
+// strict treats missing programs and directories as errors. The editor lets
+// such jobs be saved, but a strict import rejects them.
+func (v JobValidation) strict() JobValidation {
+	out := JobValidation{Errors: append([]JobIssue{}, v.Errors...), Warnings: []JobIssue{}}
+	for _, issue := range v.Warnings {
+		switch issue.Code {
+		case logErrorExecutableNotFound, logErrorWorkDirNotFound:
+			out.Errors = append(out.Errors, issue)
+		default:
+			out.Warnings = append(out.Warnings, issue)
+		}
+	}
+	out.Valid = len(out.Errors) == 0
+	return out
+}
+
+// validationError joins the errors of a failed validation into one error.
+func validationError(name string, v JobValidation) error {
+	if v.Valid {
+		return nil
+	}
+	msgs := make([]string, 0, len(v.Errors))
+	for _, issue := range v.Errors {
+		msgs = append(msgs, issue.Field+": "+issue.Message)
+	}
+	return errors.New(name + ": " + strings.Join(msgs, "; "))
+}
@@ -0,0 +1,34 @@
+package main
+
+import "testing"
+
+func TestStrictValidationRejectsMissingFiles(t *testing.T) {
+	tests := []struct {
+		name      string
+		warnings  []JobIssue
+		wantValid bool
+	}{
+		{"no issues", nil, true},
+		{"only schedule warning", []JobIssue{{Field: "cron", Code: "never_scheduled"}}, true},
+		{"missing executable", []JobIssue{{Field: "command", Code: logErrorExecutableNotFound}}, false},
+		{"missing workDir", []JobIssue{{Field: "workDir", Code: logErrorWorkDirNotFound}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := JobValidation{Valid: true, Errors: []JobIssue{}, Warnings: tt.warnings}
+			got := v.strict()
+			if got.Valid != tt.wantValid {
+				t.Errorf("strict().Valid = %v, want %v", got.Valid, tt.wantValid)
+			}
+			if len(got.Errors)+len(got.Warnings) != len(tt.warnings) {
+				t.Errorf("strict() lost issues: %+v", got)
+			}
+			if err := validationError("job", got); (err == nil) != tt.wantValid {
+				t.Errorf("validationError = %v", err)
+			}
+			if !v.Valid || len(v.Errors) != 0 {
+				t.Error("strict() changed the editor's validation")
+			}
+		})
+	}
+}
@@ -3,6 +3,8 @@ import { withJobsNextRunAt } from "../../ui/cron.js"
 import { refreshJobsAndSyncSelectedJob, requireUpdatedJob } from "./jobUpdate.js"
 
 export function createJobActions(ctx) {
+  // Nested fields are edited in the form; keep them apart from the listed job.
+  const cloneValue = (value) => JSON.parse(JSON.stringify(value))
   const normalizeArgs = (value) => Array.isArray(value) ? value.filter((s) => s !== "") : []
   const findJobById = (id) =>
     Array.isArray(ctx.jobs.value) ? ctx.jobs.value.find((j) => String(j?.id || "") === String(id || "")) : null
@@ -60,6 +62,21 @@ export function createJobActions(ctx) {
     ctx.form.concurrencyPolicy = job.concurrencyPolicy ? String(job.concurrencyPolicy) : "skip"
     ctx.form.enabled = !!job.enabled
     ctx.form.maxConsecutiveFailures = ctx.normalizeMaxConsecutiveFailures(job.maxConsecutiveFailures)
+    ctx.form.shell = String(job.shell ?? "")
+    ctx.form.script = String(job.script ?? "")
+    ctx.form.stdinSource = String(job.stdinSource ?? "")
+    ctx.form.stdin = String(job.stdin ?? "")
+    ctx.form.successExitCodes = String(job.successExitCodes ?? "")
+    ctx.form.failIfOutputMatches = String(job.failIfOutputMatches ?? "")
+    ctx.form.succeedIfOutputMatches = String(job.succeedIfOutputMatches ?? "")
+    ctx.form.stopSignal = String(job.stopSignal ?? "")
+    ctx.form.stopGracePeriod = Number(job.stopGracePeriod) || 0
+    ctx.form.outputEncoding = String(job.outputEncoding ?? "")
+    ctx.form.ansiMode = String(job.ansiMode ?? "")
+    ctx.form.params = Array.isArray(job.params) ? cloneValue(job.params) : []
+    ctx.form.steps = Array.isArray(job.steps) ? cloneValue(job.steps) : []
+    ctx.form.matrix = job.matrix ? cloneValue(job.matrix) : null
+    ctx.form.logRetention = job.logRetention ? cloneValue(job.logRetention) : null
     ctx.markFormClean()
     return true
   }
@@ -86,6 +103,21 @@ export function createJobActions(ctx) {
     ctx.form.concurrencyPolicy = "skip"
     ctx.form.enabled = true
     ctx.form.maxConsecutiveFailures = 3
+    ctx.form.shell = ""
+    ctx.form.script = ""
+    ctx.form.stdinSource = ""
+    ctx.form.stdin = ""
+    ctx.form.successExitCodes = ""
+    ctx.form.failIfOutputMatches = ""
+    ctx.form.succeedIfOutputMatches = ""
+    ctx.form.stopSignal = ""
+    ctx.form.stopGracePeriod = 0
+    ctx.form.outputEncoding = ""
+    ctx.form.ansiMode = ""
+    ctx.form.params = []
+    ctx.form.steps = []
+    ctx.form.matrix = null
+    ctx.form.logRetention = null
     ctx.markFormClean()
     return true
   }
@@ -111,7 +143,7 @@ export function createJobActions(ctx) {
       const existing = ctx.form.id ? findJobById(ctx.form.id) : null
       const existingHotkey = String(existing?.hotkey || "")
 
-      const savedRaw = await ctx.callCronT(5000, "UpsertJob", {
+      const payload = {
         id: ctx.form.id,
         name: ctx.form.name,
         folder: ctx.form.folder,
@@ -126,7 +158,33 @@ export function createJobActions(ctx) {
         concurrencyPolicy: ctx.form.concurrencyPolicy,
         enabled: ctx.form.enabled,
         maxConsecutiveFailures: ctx.normalizeMaxConsecutiveFailures(ctx.form.maxConsecutiveFailures),
-      })
+        shell: String(ctx.form.shell ?? ""),
+        script: String(ctx.form.script ?? ""),
+        stdinSource: String(ctx.form.stdinSource ?? ""),
+        stdin: String(ctx.form.stdin ?? ""),
+        successExitCodes: String(ctx.form.successExitCodes ?? ""),
+        failIfOutputMatches: String(ctx.form.failIfOutputMatches ?? ""),
+        succeedIfOutputMatches: String(ctx.form.succeedIfOutputMatches ?? ""),
+        stopSignal: String(ctx.form.stopSignal ?? ""),
+        stopGracePeriod: Number(ctx.form.stopGracePeriod) || 0,
+        outputEncoding: String(ctx.form.outputEncoding ?? ""),
+        ansiMode: String(ctx.form.ansiMode ?? ""),
+        params: ctx.form.params,
+        steps: ctx.form.steps,
+        matrix: ctx.form.matrix,
+        logRetention: ctx.form.logRetention,
+      }
+
+      const validation = ctx.normalizeObjectResult(await ctx.callCronT(5000, "ValidateJob", payload))
+      const issueText = (issues) => (Array.isArray(issues) ? issues : []).map((i) => i?.message || "").filter(Boolean).join("\n")
+      // Only definition errors block saving; a program or directory missing on
+      // this machine comes back as a warning and is shown after the save.
+      const errors = issueText(validation?.errors)
+      if (errors) {
+        throw new Error(errors)
+      }
+
+      const savedRaw = await ctx.callCronT(5000, "UpsertJob", payload)
 
       const saved = ctx.normalizeObjectResult(savedRaw)
       if (!saved?.id) {
@@ -136,7 +194,12 @@ export function createJobActions(ctx) {
       await refreshJobs()
       loadJobToForm(saved)
       await ctx.focusLogs(String(saved.id || ""))
-      ctx.dismissToast()
+      const warnings = issueText(validation?.warnings)
+      if (warnings) {
+        ctx.showToast(warnings, "info")
+      } else {
+        ctx.dismissToast()
+      }
       ctx.triggerEditorPulse("success")
     } catch (e) {
       ctx.error.value = String(e)
@@ -173,6 +236,21 @@ export function createJobActions(ctx) {
         concurrencyPolicy: job?.concurrencyPolicy ? String(job.concurrencyPolicy) : "skip",
         enabled: !!job?.enabled,
         maxConsecutiveFailures: ctx.normalizeMaxConsecutiveFailures(job?.maxConsecutiveFailures),
+        shell: String(job?.shell ?? ""),
+        script: String(job?.script ?? ""),
+        stdinSource: String(job?.stdinSource ?? ""),
+        stdin: String(job?.stdin ?? ""),
+        successExitCodes: String(job?.successExitCodes ?? ""),
+        failIfOutputMatches: String(job?.failIfOutputMatches ?? ""),
+        succeedIfOutputMatches: String(job?.succeedIfOutputMatches ?? ""),
+        stopSignal: String(job?.stopSignal ?? ""),
+        stopGracePeriod: Number(job?.stopGracePeriod) || 0,
+        outputEncoding: String(job?.outputEncoding ?? ""),
+        ansiMode: String(job?.ansiMode ?? ""),
+        params: Array.isArray(job?.params) ? job.params : [],
+        steps: Array.isArray(job?.steps) ? job.steps : [],
+        matrix: job?.matrix ?? null,
+        logRetention: job?.logRetention ?? null,
       })
 
       const saved = ctx.normalizeObjectResult(savedRaw)
@@ -308,11 +386,38 @@ export function createJobActions(ctx) {
     }
   }
 
+  function promptJobParams(job) {
+    const values = {}
+    for (const param of Array.isArray(job?.params) ? job.params : []) {
+      const name = String(param?.name || "")
+      if (!name) {
+        continue
+      }
+      const choices = Array.isArray(param?.choices) && param.choices.length ? ` [${param.choices.join(" | ")}]` : ""
+      const label = `${param?.description || name}${choices}`
+      const value = window.prompt(label, String(param?.default ?? ""))
+      if (value === null) {
+        return null
+      }
+      values[name] = value
+    }
+    return values
+  }
+
   async function runNow(jobId) {
     ctx.error.value = ""
     try {
       const job = findJobById(jobId)
-      const entryRaw = await ctx.callCronT(getRunTimeoutMs(job?.timeout), "RunNow", jobId)
+      let entryRaw
+      if (Array.isArray(job?.params) && job.params.length) {
+        const params = promptJobParams(job)
+        if (!params) {
+          return
+        }
+        entryRaw = await ctx.callCronT(getRunTimeoutMs(job?.timeout), "RunWithParams", jobId, params)
+      } else {
+        entryRaw = await ctx.callCronT(getRunTimeoutMs(job?.timeout), "RunNow", jobId)
+      }
       resolveRunEntry(entryRaw, "errors.failed_to_run_job")
     } catch (e) {
       ctx.reportError(e)
@@ -330,6 +435,20 @@ export function createJobActions(ctx) {
         inheritEnv: ctx.form.inheritEnv !== false,
         flagProcessCreation: String(ctx.form.flagProcessCreation ?? ""),
         timeout: Number(ctx.form.timeout) || 0,
+        shell: String(ctx.form.shell ?? ""),
+        script: String(ctx.form.script ?? ""),
+        stdinSource: String(ctx.form.stdinSource ?? ""),
+        stdin: String(ctx.form.stdin ?? ""),
+        successExitCodes: String(ctx.form.successExitCodes ?? ""),
+        failIfOutputMatches: String(ctx.form.failIfOutputMatches ?? ""),
+        succeedIfOutputMatches: String(ctx.form.succeedIfOutputMatches ?? ""),
+        stopSignal: String(ctx.form.stopSignal ?? ""),
+        stopGracePeriod: Number(ctx.form.stopGracePeriod) || 0,
+        outputEncoding: String(ctx.form.outputEncoding ?? ""),
+        ansiMode: String(ctx.form.ansiMode ?? ""),
+        params: ctx.form.params,
+        steps: ctx.form.steps,
+        matrix: ctx.form.matrix,
         jobId: ctx.form.id,
         jobName: ctx.form.name,
       })
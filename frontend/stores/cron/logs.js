@@ -21,6 +21,7 @@ export function createLogActions(ctx) {
     seq: 0,
     liveEntries: new Map(),
     loadedStoredCount: 0,
+    nextCursor: "",
   }
 
   const isRunningEntry = (entry) => !!String(entry?.startedAt || "").trim() && !String(entry?.finishedAt || "").trim()
@@ -117,10 +118,11 @@ export function createLogActions(ctx) {
       storedCount: Number.isFinite(storedCount) ? Math.max(0, Math.trunc(storedCount)) : items.length,
       totalCount: Number.isFinite(totalCount) ? Math.max(0, Math.trunc(totalCount)) : items.length,
       hasMore: !!page?.hasMore,
+      nextCursor: typeof page?.nextCursor === "string" ? page.nextCursor : "",
     }
   }
 
-  const applyLogPage = (list, focusJobId, { append = false, storedCount = 0, totalCount = 0, hasMore = false } = {}) => {
+  const applyLogPage = (list, focusJobId, { append = false, storedCount = 0, totalCount = 0, hasMore = false, nextCursor = "" } = {}) => {
     if (!append) {
       syncLiveEntriesFromPage(list, focusJobId)
     }
@@ -129,11 +131,13 @@ export function createLogActions(ctx) {
     state.loadedStoredCount = append ? state.loadedStoredCount + storedCount : storedCount
     ctx.logsTotalCount.value = Math.max(append ? ctx.logsTotalCount.value : 0, totalCount, source.length)
     ctx.logsHasMore.value = hasMore
+    state.nextCursor = nextCursor
     state.lastId = focusJobId
   }
 
   const resetPagination = () => {
     state.loadedStoredCount = 0
+    state.nextCursor = ""
     ctx.logsHasMore.value = false
     ctx.logsTotalCount.value = 0
     ctx.logsLoading.value = false
@@ -153,7 +157,7 @@ export function createLogActions(ctx) {
     }
   }
 
-  const requestLogPage = (id, { mode, offset = 0, append = false, reset = false, onError } = {}) => {
+  const requestLogPage = (id, { mode, cursor = "", append = false, reset = false, onError } = {}) => {
     const seq = ++state.seq
     state.inflightId = id
     state.inflightMode = mode
@@ -166,7 +170,7 @@ export function createLogActions(ctx) {
     setPagingLoading(mode, true)
     state.inflight = (async () => {
       try {
-        const result = await ctx.callCronT(5000, "ListLogsPage", id, offset, LOG_PAGE_SIZE)
+        const result = await ctx.callCronT(5000, "ListLogsPageAfter", id, "", cursor, LOG_PAGE_SIZE)
         const page = normalizeLogPage(result)
         if (seq === state.seq) {
           applyLogPage(page.items, id, {
@@ -174,6 +178,7 @@ export function createLogActions(ctx) {
             storedCount: page.storedCount,
             totalCount: page.totalCount,
             hasMore: page.hasMore,
+            nextCursor: page.nextCursor,
           })
         }
         return append ? ctx.logs.value : page.items
@@ -291,7 +296,7 @@ export function createLogActions(ctx) {
     }
 
     const needsMore = ctx.logsHasMore.value || toLogs(ctx.logs.value).length < (Number(ctx.logsTotalCount.value) || 0)
-    if (ctx.logsLoading.value || ctx.logsLoadingMore.value || !needsMore) {
+    if (ctx.logsLoading.value || ctx.logsLoadingMore.value || !needsMore || !state.nextCursor) {
       return ctx.logs.value
     }
     if (state.inflightId === id && state.inflightMode === "more" && state.inflight) {
@@ -300,7 +305,7 @@ export function createLogActions(ctx) {
 
     return requestLogPage(id, {
       mode: "more",
-      offset: state.loadedStoredCount,
+      cursor: state.nextCursor,
       append: true,
       onError: (e) => ctx.reportError(e),
     })
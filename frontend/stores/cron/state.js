@@ -53,6 +53,21 @@ export function createCronState() {
     concurrencyPolicy: "skip",
     enabled: true,
     maxConsecutiveFailures: 3,
+    shell: "",
+    script: "",
+    stdinSource: "",
+    stdin: "",
+    successExitCodes: "",
+    failIfOutputMatches: "",
+    succeedIfOutputMatches: "",
+    stopSignal: "",
+    stopGracePeriod: 0,
+    outputEncoding: "",
+    ansiMode: "",
+    params: [],
+    steps: [],
+    matrix: null,
+    logRetention: null,
   })
 
   let formBaseline = ""
@@ -79,6 +94,21 @@ export function createCronState() {
       concurrencyPolicy: String(form.concurrencyPolicy || "skip"),
       enabled: !!form.enabled,
       maxConsecutiveFailures: normalizeMaxConsecutiveFailures(form.maxConsecutiveFailures),
+      shell: String(form.shell ?? ""),
+      script: String(form.script ?? ""),
+      stdinSource: String(form.stdinSource ?? ""),
+      stdin: String(form.stdin ?? ""),
+      successExitCodes: String(form.successExitCodes ?? ""),
+      failIfOutputMatches: String(form.failIfOutputMatches ?? ""),
+      succeedIfOutputMatches: String(form.succeedIfOutputMatches ?? ""),
+      stopSignal: String(form.stopSignal ?? ""),
+      stopGracePeriod: Number(form.stopGracePeriod) || 0,
+      outputEncoding: String(form.outputEncoding ?? ""),
+      ansiMode: String(form.ansiMode ?? ""),
+      params: Array.isArray(form.params) ? form.params : [],
+      steps: Array.isArray(form.steps) ? form.steps : [],
+      matrix: form.matrix ?? null,
+      logRetention: form.logRetention ?? null,
     })
 
   const setDirtyState = (value) => {
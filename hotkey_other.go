@@ -0,0 +1,9 @@
+//go:build !windows
+
+package main
+
+// Global hotkeys are only registered on Windows; the constructor exists so the
+// service compiles elsewhere and is never reached there.
+func newWindowsHotkeyManager(onHotkey func(jobID string)) HotkeyManager {
+	return nil
+}
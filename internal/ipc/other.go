@@ -0,0 +1,40 @@
+//go:build !windows
+
+package ipc
+
+import (
+	"errors"
+	"os"
+	"strings"
+)
+
+// The control pipe is a Windows named pipe; elsewhere the package only builds
+// so the rest of the app can be compiled and tested.
+var errPipeUnsupported = errors.New("control pipe is only supported on windows")
+
+func ControlPipeUserPath() string {
+	return ""
+}
+
+func StartServer(pipePath string, allowAuthenticatedUsers bool, handler func(Request) Response) (stop func(), err error) {
+	return nil, errPipeUnsupported
+}
+
+func SendRequestToPipe(pipePath string, req Request) (Response, error) {
+	return Response{}, errPipeUnsupported
+}
+
+func SendRequest(req Request) (Response, error) {
+	return SendRequestToPipe(ControlPipeUserPath(), req)
+}
+
+func IsLikelyPipeNotRunning(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "file not found") || strings.Contains(msg, "cannot find")
+}
@@ -6,10 +6,20 @@ import (
 )
 
 type Request struct {
-	Cmd              string `json:"cmd"`
-	Target           string `json:"target,omitempty"`
-	Payload          string `json:"payload,omitempty"`
-	ConflictStrategy string `json:"conflictStrategy,omitempty"`
+	Cmd              string            `json:"cmd"`
+	Target           string            `json:"target,omitempty"`
+	Payload          string            `json:"payload,omitempty"`
+	ConflictStrategy string            `json:"conflictStrategy,omitempty"`
+	Strict           bool              `json:"strict,omitempty"`
+	Args             []string          `json:"args,omitempty"`
+	Env              map[string]string `json:"env,omitempty"`
+	WorkDir          string            `json:"workDir,omitempty"`
+	Params           map[string]string `json:"params,omitempty"`
+	From             string            `json:"from,omitempty"`
+	To               string            `json:"to,omitempty"`
+	Format           string            `json:"format,omitempty"`
+	Status           string            `json:"status,omitempty"`
+	Path             string            `json:"path,omitempty"`
 }
 
 type Response struct {
@@ -17,6 +27,8 @@ type Response struct {
 	Message       string `json:"message,omitempty"`
 	Error         string `json:"error,omitempty"`
 	GlobalEnabled *bool  `json:"globalEnabled,omitempty"`
+	// Data carries the JSON result of commands that return structured data.
+	Data json.RawMessage `json:"data,omitempty"`
 }
 
 func marshalJSONLine(v any) ([]byte, error) {
@@ -2,6 +2,7 @@ package main
 
 import (
 	"embed"
+	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
@@ -51,6 +52,8 @@ func main() {
 	cronSvc := NewCronService()
 	settingsSvc := NewSettingsService()
 	configSvc := NewConfigService(cronSvc, settingsSvc)
+	cronSvc.setLogRetention(settingsSvc.getLogRetention)
+	cronSvc.setSkippedRunLogDisabled(settingsSvc.getDisableSkippedRunLog)
 	var quitting atomic.Bool
 
 	currentBootTime := GetSystemBootTime()
@@ -272,6 +275,10 @@ func main() {
 			if target == "" {
 				return ipc.Response{Ok: false, Error: "job name is required"}
 			}
+			overrides, err := newRunOverrides(req.Args, req.Env, req.WorkDir, req.Params)
+			if err != nil {
+				return ipc.Response{Ok: false, Error: err.Error()}
+			}
 			matched, err := matchJobsByName(target)
 			if err != nil {
 				return ipc.Response{Ok: false, Error: err.Error()}
@@ -280,17 +287,68 @@ func main() {
 				return ipc.Response{Ok: false, Error: fmt.Sprintf("no matching jobs: %s", target)}
 			}
 			for _, j := range matched {
-				id := j.ID
-				go func() {
-					_, _ = cronSvc.runNow(id, logTriggerSourceIPC)
-				}()
+				if _, err := resolveJobParams(j.Params, overrides.params()); err != nil {
+					return ipc.Response{Ok: false, Error: fmt.Sprintf("%s: %v", j.Name, err)}
+				}
+			}
+			// Wait briefly so start failures reach the caller; the pipe
+			// deadline rules out waiting for the runs to finish.
+			if err := cronSvc.startRuns(matched, runOptions{triggerSource: logTriggerSourceIPC, overrides: overrides}, 2*time.Second); err != nil {
+				return ipc.Response{Ok: false, Error: err.Error()}
 			}
 			return ipc.Response{Ok: true, Message: fmt.Sprintf("started %d job(s)", len(matched))}
+		case "stats":
+			if target == "" {
+				return ipc.Response{Ok: false, Error: "job name is required"}
+			}
+			matched, err := matchJobsByName(target)
+			if err != nil {
+				return ipc.Response{Ok: false, Error: err.Error()}
+			}
+			if len(matched) == 0 {
+				return ipc.Response{Ok: false, Error: fmt.Sprintf("no matching jobs: %s", target)}
+			}
+			all := make([]JobStats, 0, len(matched))
+			for _, j := range matched {
+				stats, err := cronSvc.GetJobStats(j.ID, req.From, req.To)
+				if err != nil {
+					return ipc.Response{Ok: false, Error: err.Error()}
+				}
+				all = append(all, stats)
+			}
+			data, err := json.Marshal(all)
+			if err != nil {
+				return ipc.Response{Ok: false, Error: err.Error()}
+			}
+			return ipc.Response{Ok: true, Data: data}
+		case "export_logs":
+			filter := LogExportFilter{Status: req.Status, From: req.From, To: req.To}
+			if target != "" {
+				matched, err := matchJobsByNameOrFolder(target)
+				if err != nil {
+					return ipc.Response{Ok: false, Error: err.Error()}
+				}
+				if len(matched) == 0 {
+					return ipc.Response{Ok: false, Error: fmt.Sprintf("no matching jobs: %s", target)}
+				}
+				for _, j := range matched {
+					filter.JobIDs = append(filter.JobIDs, j.ID)
+				}
+			}
+			result, err := cronSvc.ExportLogs(filter, req.Format, req.Path)
+			if err != nil {
+				return ipc.Response{Ok: false, Error: err.Error()}
+			}
+			return ipc.Response{Ok: true, Message: fmt.Sprintf("exported %d run(s) to %s", result.Count, result.Path)}
 		case "import":
 			if strings.TrimSpace(req.Payload) == "" {
 				return ipc.Response{Ok: false, Error: "import payload is required"}
 			}
-			if err := configSvc.ImportYAML(req.Payload, req.ConflictStrategy); err != nil {
+			importYAML := configSvc.ImportYAML
+			if req.Strict {
+				importYAML = configSvc.ImportYAMLStrict
+			}
+			if err := importYAML(req.Payload, req.ConflictStrategy); err != nil {
 				return ipc.Response{Ok: false, Error: err.Error()}
 			}
 			return ipc.Response{Ok: true, Message: "imported"}
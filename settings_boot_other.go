@@ -0,0 +1,29 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GetSystemBootTime derives the boot time from /proc/uptime where it exists
+// and returns "" where it cannot be read.
+func GetSystemBootTime() string {
+	data, err := os.ReadFile("/proc/uptime")
+	if err != nil {
+		return ""
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return ""
+	}
+	seconds, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return ""
+	}
+	bootTime := time.Now().Add(-time.Duration(seconds * float64(time.Second)))
+	return bootTime.UTC().Format(time.RFC3339)
+}
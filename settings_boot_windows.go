@@ -0,0 +1,18 @@
+//go:build windows
+
+package main
+
+import (
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+// GetSystemBootTime returns the Windows system boot time as a formatted string
+func GetSystemBootTime() string {
+	// Use golang.org/x/sys/windows helper function
+	uptime := windows.DurationSinceBoot()
+	// Calculate boot time
+	bootTime := time.Now().Add(-uptime)
+	return bootTime.UTC().Format(time.RFC3339)
+}
@@ -11,16 +11,17 @@ import (
 	"strings"
 	"sync"
 	"syscall"
-	"time"
-
-	"golang.org/x/sys/windows"
 )
 
 type AppSettings struct {
-	LightweightMode bool `json:"lightweightMode,omitempty" yaml:"lightweightMode,omitempty"`
-	SilentStart     bool `json:"silentStart,omitempty" yaml:"silentStart,omitempty"`
-	AutoStart       bool `json:"autoStart,omitempty" yaml:"autoStart,omitempty"`
-	RunInTray       bool `json:"runInTray" yaml:"runInTray"`
+	LightweightMode bool         `json:"lightweightMode,omitempty" yaml:"lightweightMode,omitempty"`
+	SilentStart     bool         `json:"silentStart,omitempty" yaml:"silentStart,omitempty"`
+	AutoStart       bool         `json:"autoStart,omitempty" yaml:"autoStart,omitempty"`
+	RunInTray       bool         `json:"runInTray" yaml:"runInTray"`
+	LogRetention    LogRetention `json:"logRetention" yaml:"logRetention,omitempty"`
+	// DisableSkippedRunLog stops storing triggers that were skipped or
+	// suppressed instead of starting a run.
+	DisableSkippedRunLog bool `json:"disableSkippedRunLog,omitempty" yaml:"disableSkippedRunLog,omitempty"`
 }
 
 func defaultAppSettings() AppSettings {
@@ -55,6 +56,7 @@ func normalizeWindowSize(width int, height int) (int, int) {
 }
 
 func (d *settingsStoreData) normalize() {
+	d.LogRetention = normalizeLogRetention(d.LogRetention)
 	d.Local.WindowWidth, d.Local.WindowHeight = normalizeWindowSize(d.Local.WindowWidth, d.Local.WindowHeight)
 }
 
@@ -236,6 +238,14 @@ func (s *SettingsService) SetRunInTray(enabled bool) error {
 	return s.updateAndPersist(func(data *settingsStoreData) { data.AppSettings.RunInTray = enabled })
 }
 
+func (s *SettingsService) SetLogRetention(retention LogRetention) error {
+	return s.updateAndPersist(func(data *settingsStoreData) { data.AppSettings.LogRetention = retention })
+}
+
+func (s *SettingsService) SetDisableSkippedRunLog(disabled bool) error {
+	return s.updateAndPersist(func(data *settingsStoreData) { data.AppSettings.DisableSkippedRunLog = disabled })
+}
+
 func (s *SettingsService) getRunInTray() bool {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -260,6 +270,18 @@ func (s *SettingsService) getAutoStart() bool {
 	return s.data.AppSettings.AutoStart
 }
 
+func (s *SettingsService) getLogRetention() LogRetention {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.AppSettings.LogRetention
+}
+
+func (s *SettingsService) getDisableSkippedRunLog() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.AppSettings.DisableSkippedRunLog
+}
+
 func (s *SettingsService) getWindowSize() (width int, height int) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -309,15 +331,6 @@ func (s *SettingsService) OpenEnvironmentVariables() error {
 	return nil
 }
 
-// GetSystemBootTime returns the Windows system boot time as a formatted string
-func GetSystemBootTime() string {
-	// Use golang.org/x/sys/windows helper function
-	uptime := windows.DurationSinceBoot()
-	// Calculate boot time
-	bootTime := time.Now().Add(-uptime)
-	return bootTime.UTC().Format(time.RFC3339)
-}
-
 // GetLastSystemBootTime returns the stored last system boot time
 func (s *SettingsService) GetLastSystemBootTime() string {
 	s.mu.RLock()